@@ -1,39 +1,155 @@
 package main
 
 import (
+	"context"
 	"log"
 	httpNet "net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"blog-api/config"
 	"blog-api/internal/delivery/http"
+	"blog-api/internal/delivery/worker"
 	"blog-api/internal/repository/mysql"
 	"blog-api/internal/usecase"
 	"blog-api/pkg/db"
+	"blog-api/pkg/jwt"
+	"blog-api/pkg/middleware"
+	"blog-api/pkg/storage"
 
 	_ "github.com/go-sql-driver/mysql"
-	"github.com/gorilla/mux"
 )
 
 func main() {
 	// Load config
 	cfg := config.LoadConfig()
 	dbConn := db.ConnectDB(cfg)
-	if err := db.InitializeDB(dbConn, cfg); err != nil {
-		log.Fatalf("Error initializing the database: %v", err)
+
+	// "api migrate ..." manages the schema and "api set-role ..." appoints
+	// users instead of starting the server
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			if err := runMigrate(cfg, os.Args[2:]); err != nil {
+				log.Fatalf("Error migrating the database: %v", err)
+			}
+		case "set-role":
+			if err := runSetRole(dbConn, cfg, os.Args[2:]); err != nil {
+				log.Fatalf("Error setting the role: %v", err)
+			}
+		default:
+			log.Fatalf("Unknown command %q, %s or %s", os.Args[1], migrateUsage, setRoleUsage)
+		}
+		dbConn.Close()
+		return
+	}
+
+	// Bring the schema up to date before serving; replicas starting together
+	// wait for each other
+	migrator, err := db.NewMigrator(cfg)
+	if err != nil {
+		log.Fatalf("Error loading migrations: %v", err)
+	}
+	if err := migrateUp(context.Background(), migrator); err != nil {
+		log.Fatalf("Error migrating the database: %v", err)
+	}
+	migrator.Close()
+
+	store, err := storage.New(cfg, cfg.BaseURL+http.APIPrefix)
+	if err != nil {
+		log.Fatalf("Error setting up media storage: %v", err)
+	}
+
+	userRepo := mysql.NewUserRepository(dbConn, cfg.QueryTimeout)
+	// Load the signing keys, creating the first one on a fresh database
+	keys := jwt.NewKeySet()
+	signingKeyRepo := mysql.NewSigningKeyRepository(dbConn, cfg.QueryTimeout)
+	signingKeyUsecase := usecase.NewSigningKeyUsecase(signingKeyRepo, keys, cfg.JWTAlgorithm, cfg.JWTKeyRotation, cfg.JWTKeyOverlap, cfg.AccessTokenTTL)
+	if err := signingKeyUsecase.RotateKeys(context.Background(), time.Now()); err != nil {
+		log.Fatalf("Error loading signing keys: %v", err)
+	}
+
+	tokenRepo := mysql.NewTokenRepository(dbConn, cfg.QueryTimeout)
+	signer := jwt.NewSigner(keys, cfg.JWTIssuer, cfg.JWTAudience)
+	userUsecase := usecase.NewUserUsecase(userRepo, tokenRepo, signer, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	verifier := jwt.NewVerifier(keys, cfg.JWTIssuer, cfg.JWTAudience, userUsecase)
+	authn := middleware.NewAuth(verifier)
+
+	categoryRepo := mysql.NewCategoryRepository(dbConn, cfg.QueryTimeout)
+	categoryUsecase := usecase.NewCategoryUsecase(categoryRepo)
+
+	mediaRepo := mysql.NewMediaRepository(dbConn, cfg.QueryTimeout)
+	mediaUsecase := usecase.NewMediaUsecase(mediaRepo, store)
+
+	blogRepo := mysql.NewBlogRepository(dbConn, cfg.QueryTimeout)
+	blogUsecase := usecase.NewBlogUsecase(blogRepo, categoryRepo, mediaRepo, store)
+	// Blogs written before slugs existed get theirs before they are served
+	if n, err := blogUsecase.BackfillSlugs(context.Background()); err != nil {
+		log.Fatalf("Error backfilling blog slugs: %v", err)
+	} else if n > 0 {
+		log.Printf("Gave %d blogs a slug", n)
 	}
 
-	userRepo := mysql.NewUserRepository(dbConn)
-	userUsecase := usecase.NewUserUsecase(userRepo, cfg.JWTSecret)
-	log.Println(userUsecase)
+	commentRepo := mysql.NewCommentRepository(dbConn, cfg.QueryTimeout)
+	commentUsecase := usecase.NewCommentUsecase(commentRepo, blogRepo)
+
+	// All routes are mounted under the API version prefix
+	r, api := http.NewRouter()
+
+	http.NewJWKSHandler(r, keys)
+	http.NewUserHandler(api, userUsecase, authn)
+	http.NewBlogHandler(api, blogUsecase, authn, cfg.BaseURL)
+	http.NewCategoryHandler(api, categoryUsecase, authn)
+	http.NewCommentHandler(api, commentUsecase, authn)
+	http.NewMediaHandler(api, mediaUsecase, store, authn, cfg.BaseURL, cfg.SignedURLTTL)
 
-	blogRepo := mysql.NewBlogRepository(dbConn)
-	blogUsecase := usecase.NewBlogUsecase(blogRepo)
+	// Stop the server and background workers on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	r := mux.NewRouter()
+	var workers sync.WaitGroup
+	publisher := worker.NewPublisher(blogUsecase, cfg.PublishInterval)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		publisher.Run(ctx)
+	}()
 
-	http.NewUserHandler(r, userUsecase)
-	http.NewBlogHandler(r, blogUsecase, config.LoadConfig().JWTSecret)
+	fileCleaner := worker.NewFileCleaner(mediaUsecase, cfg.FileCleanupInterval)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		fileCleaner.Run(ctx)
+	}()
+
+	keyRotator := worker.NewKeyRotator(signingKeyUsecase, cfg.JWTKeyRefresh)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		keyRotator.Run(ctx)
+	}()
+
+	server := &httpNet.Server{Addr: ":8080", Handler: r}
+	go func() {
+		log.Println("Server is running on port 8080")
+		if err := server.ListenAndServe(); err != nil && err != httpNet.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down the server...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down the server: %v", err)
+	}
 
-	log.Println("Server is running on port 8080")
-	log.Fatal(httpNet.ListenAndServe(":8080", r))
+	workers.Wait()
+	dbConn.Close()
+	log.Println("Server stopped")
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"blog-api/config"
+	"blog-api/pkg/db"
+)
+
+const migrateUsage = "usage: api migrate up | down [steps] | status"
+
+// runMigrate runs the "migrate" subcommand: "up" applies pending migrations,
+// "down" rolls back the last one (or the given number of them) and "status"
+// lists every migration.
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := db.NewMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	ctx := context.Background()
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		return migrateUp(ctx, migrator)
+
+	case args[0] == "down" && len(args) <= 2:
+		steps := 1
+		if len(args) == 2 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive number, got %q", args[1])
+			}
+		}
+
+		rolledBack, err := migrator.Down(ctx, steps)
+		for _, migration := range rolledBack {
+			log.Printf("Rolled back migration %04d_%s", migration.Version, migration.Name)
+		}
+		if err == nil && len(rolledBack) == 0 {
+			log.Println("No migrations to roll back")
+		}
+		return err
+
+	case args[0] == "status" && len(args) == 1:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, applied)
+		}
+		return nil
+	}
+	return errors.New(migrateUsage)
+}
+
+// migrateUp applies the pending migrations, logging each one
+func migrateUp(ctx context.Context, migrator *db.Migrator) error {
+	applied, err := migrator.Up(ctx)
+	for _, migration := range applied {
+		log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+	}
+	if err == nil && len(applied) == 0 {
+		log.Println("Database schema is up to date")
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"blog-api/config"
+	"blog-api/internal/repository/mysql"
+	"blog-api/pkg/auth"
+)
+
+const setRoleUsage = "usage: api set-role <username> <user|author|admin>"
+
+// runSetRole runs the "set-role" subcommand, which gives a user another
+// role. It is how the first admin is appointed; later ones can be appointed
+// through the API.
+func runSetRole(dbConn *sql.DB, cfg *config.Config, args []string) error {
+	if len(args) != 2 {
+		return errors.New(setRoleUsage)
+	}
+	username, role := args[0], args[1]
+	if !auth.ValidRole(role) {
+		return fmt.Errorf("unknown role %q, %s", role, setRoleUsage)
+	}
+
+	ctx := context.Background()
+	userRepo := mysql.NewUserRepository(dbConn, cfg.QueryTimeout)
+	user, err := userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("user %q: %w", username, err)
+	}
+	if err := userRepo.UpdateRole(ctx, user.ID, role); err != nil {
+		return err
+	}
+
+	// Like a role change through the API, this ends the user's sessions
+	tokenRepo := mysql.NewTokenRepository(dbConn, cfg.QueryTimeout)
+	if err := tokenRepo.RevokeUserFamilies(ctx, user.ID); err != nil {
+		return err
+	}
+
+	log.Printf("User %s now has the %s role, effective from their next sign in", user.Username, role)
+	return nil
+}
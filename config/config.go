@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,7 +15,56 @@ type Config struct {
 	DBName     string
 	DBHost     string
 	DBPort     string
-	JWTSecret  string
+
+	// BaseURL is the public origin media URLs are built from, e.g.
+	// "https://blog.example.com". When empty the request's host is used.
+	BaseURL string
+
+	// QueryTimeout bounds each repository call, so a slow query cannot hold
+	// a request or the publisher indefinitely
+	QueryTimeout time.Duration
+
+	// AccessTokenTTL is how long an access token is accepted; RefreshTokenTTL
+	// is how long a session can be kept alive by refreshing
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// JWTAlgorithm is what access tokens are signed with: "RS256" or
+	// "EdDSA". JWTIssuer and JWTAudience are set as their iss and aud claims,
+	// and tokens without them are rejected.
+	JWTAlgorithm string
+	JWTIssuer    string
+	JWTAudience  string
+	// JWTKeyRotation is how long a signing key is used before the next one
+	// takes over. The next key is published JWTKeyOverlap ahead of time,
+	// which must exceed JWTKeyRefresh, how often keys are reloaded, and the
+	// time verifiers cache the JWKS.
+	JWTKeyRotation time.Duration
+	JWTKeyOverlap  time.Duration
+	JWTKeyRefresh  time.Duration
+
+	// PublishInterval is how often scheduled blogs are checked for publication
+	PublishInterval time.Duration
+	// FileCleanupInterval is how often unused media files are removed
+	FileCleanupInterval time.Duration
+
+	// StorageDriver selects where media is stored: "local" or "s3". Local
+	// media is public to anyone with its URL; media that must stay private
+	// needs "s3", the only driver with expiring signed URLs.
+	StorageDriver string
+	// StorageDir is the directory local media keys are resolved against
+	StorageDir string
+	// S3Endpoint, S3Region, S3Bucket, S3AccessKey and S3SecretKey describe
+	// the bucket used by the "s3" driver
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	// S3PathStyle addresses the bucket by path, as MinIO expects
+	S3PathStyle bool
+	// SignedURLTTL is how long signed media URLs stay valid
+	SignedURLTTL time.Duration
 }
 
 func LoadConfig() *Config {
@@ -28,6 +79,52 @@ func LoadConfig() *Config {
 		DBName:     os.Getenv("DB_NAME"),
 		DBHost:     os.Getenv("DB_HOST"),
 		DBPort:     os.Getenv("DB_PORT"),
-		JWTSecret:  os.Getenv("JWT_SECRET"),
+		BaseURL:    strings.TrimRight(os.Getenv("BASE_URL"), "/"),
+
+		QueryTimeout:        getDuration("QUERY_TIMEOUT", 5*time.Second),
+		PublishInterval:     getDuration("PUBLISH_INTERVAL", 30*time.Second),
+		FileCleanupInterval: getDuration("FILE_CLEANUP_INTERVAL", 5*time.Minute),
+		AccessTokenTTL:      getDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL:     getDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		JWTAlgorithm:   getString("JWT_ALGORITHM", "RS256"),
+		JWTIssuer:      getString("JWT_ISSUER", "blog-api"),
+		JWTAudience:    getString("JWT_AUDIENCE", "blog-api"),
+		JWTKeyRotation: getDuration("JWT_KEY_ROTATION", 30*24*time.Hour),
+		JWTKeyOverlap:  getDuration("JWT_KEY_OVERLAP", time.Hour),
+		JWTKeyRefresh:  getDuration("JWT_KEY_REFRESH", time.Minute),
+
+		StorageDriver: getString("STORAGE_DRIVER", "local"),
+		StorageDir:    getString("STORAGE_DIR", "."),
+		S3Endpoint:    os.Getenv("S3_ENDPOINT"),
+		S3Region:      os.Getenv("S3_REGION"),
+		S3Bucket:      os.Getenv("S3_BUCKET"),
+		S3AccessKey:   os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:   os.Getenv("S3_SECRET_KEY"),
+		S3PathStyle:   os.Getenv("S3_PATH_STYLE") == "true",
+		SignedURLTTL:  getDuration("SIGNED_URL_TTL", 15*time.Minute),
+	}
+}
+
+// getString reads a value from the environment, falling back to def
+func getString(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// getDuration reads a duration such as "30s" from the environment, falling back to def
+func getDuration(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s %q, using %s", key, value, def)
+		return def
 	}
+	return d
 }
@@ -4,12 +4,11 @@ go 1.22.1
 
 require (
 	github.com/go-sql-driver/mysql v1.8.1
+	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/gorilla/mux v1.8.1
 	github.com/joho/godotenv v1.5.1
+	golang.org/x/crypto v0.29.0
+	golang.org/x/image v0.23.0
 )
 
-require (
-	filippo.io/edwards25519 v1.1.0 // indirect
-	github.com/dgrijalva/jwt-go v3.2.0+incompatible // indirect
-	golang.org/x/crypto v0.29.0 // indirect
-)
+require filippo.io/edwards25519 v1.1.0 // indirect
@@ -1,42 +1,56 @@
 package http
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"log"
 	"net/http"
-	"os"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
-	"blog-api/config"
 	"blog-api/internal/entity"
 	"blog-api/internal/usecase"
-	"blog-api/pkg/jwt"
+	"blog-api/pkg/auth"
 	"blog-api/pkg/middleware"
+	"blog-api/pkg/response"
 
 	"github.com/gorilla/mux"
 )
 
 type BlogHandler struct {
 	BlogUsecase usecase.BlogUsecase
+	baseURL     string
 }
 
-func NewBlogHandler(r *mux.Router, blogUsecase usecase.BlogUsecase, secretKey string) {
+func NewBlogHandler(r *mux.Router, blogUsecase usecase.BlogUsecase, authn *middleware.Auth, baseURL string) {
 	handler := &BlogHandler{
 		BlogUsecase: blogUsecase,
+		baseURL:     baseURL,
 	}
 
-	// User can read all blogs and post cmment
-	r.HandleFunc("/blogs", handler.GetAllBlogs).Methods("GET")
-	r.HandleFunc("/blogs/{id}", handler.GetBlogByID).Methods("GET")
-	r.Handle("/comments/{blogID}", middleware.AuthMiddleware(secretKey, http.HandlerFunc(handler.CreateComment))).Methods("POST")
-
-	// Author can create, update and delete blogs
-	r.Handle("/blogs", middleware.AuthorMiddleware(secretKey)(http.HandlerFunc(handler.CreateBlog))).Methods("POST")
-	r.Handle("/blogs/{id}", middleware.AuthorMiddleware(secretKey)(http.HandlerFunc(handler.UpdateBlog))).Methods("PUT")
-	r.Handle("/blogs/{id}", middleware.AuthorMiddleware(secretKey)(http.HandlerFunc(handler.DeleteBlog))).Methods("DELETE")
+	// User can read all blogs
+	r.Handle("/blogs", authn.Optional(http.HandlerFunc(handler.GetAllBlogs))).Methods("GET")
+	r.Handle("/blogs/search", authn.Optional(http.HandlerFunc(handler.SearchBlogs))).Methods("GET")
+	r.Handle("/blogs/by-slug/{slug}", authn.Optional(http.HandlerFunc(handler.GetBlogBySlug))).Methods("GET")
+	r.Handle("/blogs/{id}", authn.Optional(http.HandlerFunc(handler.GetBlogByID))).Methods("GET")
+	r.HandleFunc("/tags", handler.GetTags).Methods("GET")
+	r.Handle("/tags/{tag}/blogs", authn.Optional(http.HandlerFunc(handler.GetBlogsByTag))).Methods("GET")
+	r.Handle("/categories/{slug}/blogs", authn.Optional(http.HandlerFunc(handler.GetBlogsByCategory))).Methods("GET")
+
+	// Authors can create, update and delete their blogs; admins anyone's
+	r.Handle("/blogs", authn.Permission(auth.PermBlogWrite)(http.HandlerFunc(handler.CreateBlog))).Methods("POST")
+	r.Handle("/blogs/{id}", authn.Permission(auth.PermBlogWrite)(http.HandlerFunc(handler.UpdateBlog))).Methods("PUT")
+	r.Handle("/blogs/{id}", authn.Permission(auth.PermBlogWrite)(http.HandlerFunc(handler.DeleteBlog))).Methods("DELETE")
+	r.Handle("/blogs/{id}/publish", authn.Permission(auth.PermBlogWrite)(handler.ChangeStatus(entity.BlogStatusPublished))).Methods("POST")
+	r.Handle("/blogs/{id}/unpublish", authn.Permission(auth.PermBlogWrite)(handler.ChangeStatus(entity.BlogStatusDraft))).Methods("POST")
+	r.Handle("/blogs/{id}/schedule", authn.Permission(auth.PermBlogWrite)(http.HandlerFunc(handler.ScheduleBlog))).Methods("POST")
+	r.Handle("/blogs/{id}/comment-mode", authn.Permission(auth.PermBlogWrite)(http.HandlerFunc(handler.SetCommentMode))).Methods("PUT")
+	r.Handle("/blogs/{id}/archive", authn.Permission(auth.PermBlogWrite)(handler.ChangeStatus(entity.BlogStatusArchived))).Methods("POST")
+
+	// Authors can browse, compare and restore revisions of their blogs
+	r.Handle("/blogs/{id}/revisions", authn.Permission(auth.PermBlogWrite)(http.HandlerFunc(handler.GetRevisions))).Methods("GET")
+	r.Handle("/blogs/{id}/revisions/diff", authn.Permission(auth.PermBlogWrite)(http.HandlerFunc(handler.DiffRevisions))).Methods("GET")
+	r.Handle("/blogs/{id}/revisions/{revision}/restore", authn.Permission(auth.PermBlogWrite)(http.HandlerFunc(handler.RestoreRevision))).Methods("POST")
 
 }
 
@@ -44,140 +58,236 @@ func (h *BlogHandler) CreateBlog(w http.ResponseWriter, r *http.Request) {
 	// Parse the form data to handle both fields and files
 	err := r.ParseMultipartForm(10 << 20) // Limit file size to 10MB
 	if err != nil {
-		http.Error(w, "Unable to parse form", http.StatusBadRequest)
+		response.Error(w, r, http.StatusBadRequest, response.CodeBadRequest, "Request body must be a multipart form")
 		return
 	}
 
 	// Extract text fields (title, content, etc.)
 	title := r.FormValue("title")
 	content := r.FormValue("content")
+	status := r.FormValue("status")
+	commentMode := r.FormValue("comment_mode")
 
-	// Extract the file (thumbnail)
-	thumbnailFile, _, err := r.FormFile("thumbnail")
+	// An optional publish_at (RFC 3339) schedules the blog instead of publishing it
+	var publishAt *time.Time
+	if value := r.FormValue("publish_at"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeInputError(w, r, invalidField("publish_at", "must be an RFC 3339 timestamp"))
+			return
+		}
+		publishAt = &t
+	}
+
+	tags, _ := formTags(r)
+	categoryID, _, err := formCategoryID(r)
 	if err != nil {
-		http.Error(w, "Thumbnail image is required", http.StatusBadRequest)
+		writeInputError(w, r, err)
 		return
 	}
-	defer thumbnailFile.Close()
 
 	// Validate that the title and content are not empty
-	if title == "" || content == "" {
-		http.Error(w, "Title and content are required", http.StatusBadRequest)
+	details := requireField(nil, "title", title)
+	details = requireField(details, "content", content)
+	if len(details) > 0 {
+		writeValidationError(w, r, details...)
 		return
 	}
 
 	// Assuming userID is extracted from the JWT token or context
-	userID, ok := r.Context().Value(jwt.UserIDKey).(int)
+	userID, ok := auth.UserID(r.Context())
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		writeUnauthorized(w, r)
 		return
 	}
 
-	// Ensure the "uploads" directory exists
-	err = os.MkdirAll("uploads", os.ModePerm)
+	// The thumbnail is either uploaded or picked from the media library
+	thumbnailPath, err := h.formThumbnail(r, userID)
 	if err != nil {
-		log.Println("Error creating uploads directory:", err)
-		http.Error(w, "Unble to create directory for thumbnail", http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
+	}
+	if thumbnailPath == "" {
+		writeValidationError(w, r, response.FieldError{Field: "thumbnail", Message: "is required unless thumbnail_media_id is sent"})
 		return
 	}
 
-	// Generate a file name for the thumbnail and save it
-	thumbnailPath := "uploads/" + "thumbnail.jpg" // Store the thumbnail in the "uploads" folder
-	outFile, err := os.Create(thumbnailPath)
+	// Create the blog entity
+	blog := &entity.Blog{
+		Title:       title,
+		Content:     content,
+		UserID:      userID,
+		Thumbnail:   thumbnailPath,
+		CategoryID:  categoryID,
+		Tags:        tags,
+		Status:      status,
+		CommentMode: commentMode,
+		PublishAt:   publishAt,
+	}
+
+	// Save the blog in the database
+	if err := h.BlogUsecase.Create(r.Context(), blog); err != nil {
+		h.BlogUsecase.DiscardThumbnail(r.Context(), thumbnailPath)
+		writeError(w, r, err)
+		return
+	}
+
+	location := fmt.Sprintf("%s/blogs/%d", APIPrefix, blog.ID)
+	response.Created(w, location, newBlogResponse(r, h.baseURL, blog))
+}
+
+func (h *BlogHandler) GetAllBlogs(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseBlogFilter(r)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, "Unable to save thumbnail", http.StatusInternalServerError)
+		writeInputError(w, r, err)
 		return
 	}
-	defer outFile.Close()
+	h.listBlogs(w, r, filter)
+}
 
-	// Copy the uploaded thumbnail to the file
-	_, err = io.Copy(outFile, thumbnailFile)
+func (h *BlogHandler) GetBlogsByTag(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseBlogFilter(r)
 	if err != nil {
-		http.Error(w, "Unable to save thumbnail", http.StatusInternalServerError)
+		writeInputError(w, r, err)
 		return
 	}
+	filter.Tag = strings.ToLower(mux.Vars(r)["tag"])
+	h.listBlogs(w, r, filter)
+}
 
-	// Create the blog entity
-	blog := &entity.Blog{
-		Title:     title,
-		Content:   content,
-		UserID:    userID,
-		Thumbnail: thumbnailPath,
+func (h *BlogHandler) GetBlogsByCategory(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseBlogFilter(r)
+	if err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+	filter.Category = mux.Vars(r)["slug"]
+	h.listBlogs(w, r, filter)
+}
+
+func (h *BlogHandler) GetTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.BlogUsecase.GetTags(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	// Log the user ID to ensure it is correct
-	log.Printf("Creating blog with user ID: %d", userID)
+	response.JSON(w, http.StatusOK, newTagResponses(tags))
+}
 
-	// Save the blog in the database
-	if err := h.BlogUsecase.Create(blog); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+// listBlogs writes one page of the blogs matching the filter, with links to the adjacent pages
+func (h *BlogHandler) listBlogs(w http.ResponseWriter, r *http.Request, filter *entity.BlogFilter) {
+	filter.ViewerID = viewerID(r)
+
+	page, err := h.BlogUsecase.GetAll(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	// Respond with success
-	w.WriteHeader(http.StatusCreated)
+	resp := &pageResponse{
+		Data:  newBlogResponses(r, h.baseURL, page.Blogs),
+		Total: page.Total,
+		Limit: filter.Limit,
+	}
+	if filter.After != nil {
+		// Cursor based pages can only move forward
+		if page.HasMore {
+			resp.Next = stringPtr(pageLink(r, "cursor", page.NextCursor))
+		}
+	} else {
+		resp = newPageResponse(r, resp.Data, page.Total, filter.Page, filter.Limit, page.HasMore)
+	}
+	if page.NextCursor != "" {
+		resp.NextCursor = stringPtr(page.NextCursor)
+	}
+
+	response.JSON(w, http.StatusOK, resp)
 }
 
-func (h *BlogHandler) GetAllBlogs(w http.ResponseWriter, r *http.Request) {
-	blogs, err := h.BlogUsecase.GetAll()
+func (h *BlogHandler) SearchBlogs(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		writeValidationError(w, r, response.FieldError{Field: "q", Message: "is required"})
+		return
+	}
+
+	filter, err := parseBlogFilter(r)
+	if err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+	filter.ViewerID = viewerID(r)
+
+	page, err := h.BlogUsecase.Search(r.Context(), query, filter)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(blogs)
+	results := newSearchResultResponses(r, h.baseURL, page.Results)
+	response.JSON(w, http.StatusOK, newPageResponse(r, results, page.Total, filter.Page, filter.Limit, page.HasMore))
 }
 
 func (h *BlogHandler) GetBlogByID(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	id, err := pathID(r, "id")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeInputError(w, r, err)
 		return
 	}
 
-	blog, err := h.BlogUsecase.GetByID(id)
+	// Drafts and archived blogs are only visible to their author
+	blog, err := h.BlogUsecase.GetVisibleByID(r.Context(), id, viewerID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(blog)
+	response.JSON(w, http.StatusOK, newBlogResponse(r, h.baseURL, blog))
 }
 
-func (h *BlogHandler) UpdateBlog(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(mux.Vars(r)["id"])
+func (h *BlogHandler) GetBlogBySlug(w http.ResponseWriter, r *http.Request) {
+	slug := mux.Vars(r)["slug"]
+
+	blog, canonical, err := h.BlogUsecase.GetVisibleBySlug(r.Context(), slug, viewerID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	secretKey := config.LoadConfig().JWTSecret
-	// Extract user ID from JWT token
-	claims, err := jwt.ExtractClaims(r, secretKey)
-	if err != nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	// Outdated slugs permanently redirect to the canonical one
+	if blog == nil {
+		http.Redirect(w, r, APIPrefix+"/blogs/by-slug/"+url.PathEscape(canonical), http.StatusMovedPermanently)
 		return
 	}
-	userID := claims.UserID
 
-	// Retrieve the existing blog from the database
-	existingBlog, err := h.BlogUsecase.GetByID(id)
+	response.JSON(w, http.StatusOK, newBlogResponse(r, h.baseURL, blog))
+}
+
+func (h *BlogHandler) UpdateBlog(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeInputError(w, r, err)
 		return
 	}
 
-	// Check if the user is the author of the blog
-	if existingBlog.UserID != userID {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	// Retrieve the existing blog from the database
+	existingBlog, err := h.BlogUsecase.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	// Parse the form data to handle both fields and files
 	err = r.ParseMultipartForm(10 << 20) // Limit file size to 10MB
 	if err != nil {
-		http.Error(w, "Unable to parse form", http.StatusBadRequest)
+		response.Error(w, r, http.StatusBadRequest, response.CodeBadRequest, "Request body must be a multipart form")
 		return
 	}
 
@@ -186,118 +296,377 @@ func (h *BlogHandler) UpdateBlog(w http.ResponseWriter, r *http.Request) {
 	content := r.FormValue("content")
 
 	// Validate that the title and content are not empty
-	if title == "" || content == "" {
-		http.Error(w, "Title and content are required", http.StatusBadRequest)
+	details := requireField(nil, "title", title)
+	details = requireField(details, "content", content)
+	if len(details) > 0 {
+		writeValidationError(w, r, details...)
+		return
+	}
+
+	// A new thumbnail may be uploaded or picked from the media library
+	thumbnailPath, err := h.formThumbnail(r, principal.UserID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Update the blog entity
+	existingBlog.Title = title
+	existingBlog.Content = content
+	if thumbnailPath != "" {
+		existingBlog.Thumbnail = thumbnailPath
+	}
+
+	// Tags and category are only changed when the fields are sent
+	if tags, ok := formTags(r); ok {
+		existingBlog.Tags = tags
+	}
+	categoryID, ok, err := formCategoryID(r)
+	if err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+	if ok {
+		existingBlog.CategoryID = categoryID
+	}
+
+	// Save the updated blog in the database
+	if err := h.BlogUsecase.Update(r.Context(), existingBlog, principal); err != nil {
+		h.BlogUsecase.DiscardThumbnail(r.Context(), thumbnailPath)
+		writeError(w, r, err)
+		return
+	}
+
+	// Respond with success
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *BlogHandler) DeleteBlog(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		writeInputError(w, r, err)
 		return
 	}
 
-	// Extract the file (thumbnail) if provided
-	thumbnailFile, _, err := r.FormFile("thumbnail")
-	var thumbnailPath string
-	if err == nil {
-		defer thumbnailFile.Close()
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, r)
+		return
+	}
 
-		// Ensure the "uploads" directory exists
-		err = os.MkdirAll("uploads", os.ModePerm)
+	// Only the author of the blog and admins may delete it
+	if err := h.BlogUsecase.Delete(r.Context(), id, principal); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangeStatus returns a handler that moves the blog to the given status
+func (h *BlogHandler) ChangeStatus(status string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := pathID(r, "id")
 		if err != nil {
-			log.Println("Error creating uploads directory:", err)
-			http.Error(w, "Unable to create directory for thumbnail", http.StatusInternalServerError)
+			writeInputError(w, r, err)
 			return
 		}
 
-		// Generate a file name for the thumbnail and save it
-		thumbnailPath = "uploads/" + "thumbnail.jpg" // Store the thumbnail in the "uploads" folder
-		outFile, err := os.Create(thumbnailPath)
-		if err != nil {
-			log.Println(err)
-			http.Error(w, "Unable to save thumbnail", http.StatusInternalServerError)
+		principal, ok := auth.FromContext(r.Context())
+		if !ok {
+			writeUnauthorized(w, r)
 			return
 		}
-		defer outFile.Close()
 
-		// Copy the uploaded thumbnail to the file
-		_, err = io.Copy(outFile, thumbnailFile)
+		blog, err := h.BlogUsecase.ChangeStatus(r.Context(), id, principal, status)
 		if err != nil {
-			http.Error(w, "Unable to save thumbnail", http.StatusInternalServerError)
+			writeError(w, r, err)
 			return
 		}
+
+		response.JSON(w, http.StatusOK, newBlogResponse(r, h.baseURL, blog))
+	})
+}
+
+func (h *BlogHandler) ScheduleBlog(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		writeInputError(w, r, err)
+		return
 	}
 
-	// Update the blog entity
-	existingBlog.Title = title
-	existingBlog.Content = content
-	if thumbnailPath != "" {
-		existingBlog.Thumbnail = thumbnailPath
+	var req struct {
+		PublishAt time.Time `json:"publish_at"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+	if req.PublishAt.IsZero() {
+		writeValidationError(w, r, response.FieldError{Field: "publish_at", Message: "is required"})
+		return
 	}
 
-	// Save the updated blog in the database
-	if err := h.BlogUsecase.Update(existingBlog); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, r)
 		return
 	}
 
-	// Respond with success
-	w.WriteHeader(http.StatusNoContent)
+	blog, err := h.BlogUsecase.Schedule(r.Context(), id, principal, req.PublishAt)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newBlogResponse(r, h.baseURL, blog))
 }
 
-func (h *BlogHandler) DeleteBlog(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(mux.Vars(r)["id"])
+func (h *BlogHandler) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeInputError(w, r, err)
 		return
 	}
 
-	// Delete blog using usecase
-	if err := h.BlogUsecase.Delete(id); err != nil {
-		// If blog does not exist, respond with 404 Not Found
-		if strings.Contains(err.Error(), "not found") {
-			http.Error(w, err.Error(), http.StatusNotFound)
-		} else {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		}
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, r)
 		return
 	}
 
-	// Log success
-	fmt.Println("Successfully deleted blog with ID:", id)
+	revisions, err := h.BlogUsecase.GetRevisions(r.Context(), id, principal)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-	w.WriteHeader(http.StatusNoContent)
+	response.JSON(w, http.StatusOK, newRevisionResponses(r, h.baseURL, revisions))
 }
 
-func (h *BlogHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
-	var comment entity.Comment
-	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+func (h *BlogHandler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		writeInputError(w, r, invalidField("from", "must be a revision number"))
+		return
+	}
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		writeInputError(w, r, invalidField("to", "must be a revision number"))
+		return
+	}
+
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, r)
 		return
 	}
 
-	blogID, err := strconv.Atoi(mux.Vars(r)["blogID"])
+	diff, err := h.BlogUsecase.DiffRevisions(r.Context(), id, principal, from, to)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	// Check that the blog exists
-	_, err = h.BlogUsecase.GetByID(blogID)
+	response.JSON(w, http.StatusOK, newRevisionDiffResponse(diff))
+}
+
+func (h *BlogHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
 	if err != nil {
-		http.Error(w, "Blog not found", http.StatusNotFound)
+		writeInputError(w, r, err)
 		return
 	}
 
-	comment.BlogID = blogID
+	revision, err := pathID(r, "revision")
+	if err != nil {
+		writeInputError(w, r, err)
+		return
+	}
 
-	// Assuming user ID is extracted from context or JWT token
-	userID, ok := r.Context().Value("user_id").(int)
+	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		writeUnauthorized(w, r)
 		return
 	}
-	comment.UserID = userID
 
-	if err := h.BlogUsecase.CreateComment(&comment); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	blog, err := h.BlogUsecase.RestoreRevision(r.Context(), id, principal, revision)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newBlogResponse(r, h.baseURL, blog))
+}
+
+func (h *BlogHandler) SetCommentMode(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+
+	var req struct {
+		Mode string `json:"mode"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, r)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
+	blog, err := h.BlogUsecase.SetCommentMode(r.Context(), id, principal, req.Mode)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newBlogResponse(r, h.baseURL, blog))
+}
+
+// viewerID returns the ID of the signed in user, or 0 for anonymous readers
+func viewerID(r *http.Request) int {
+	userID, _ := auth.UserID(r.Context())
+	return userID
+}
+
+// parseBlogFilter reads the pagination, sorting and filtering query parameters
+func parseBlogFilter(r *http.Request) (*entity.BlogFilter, error) {
+	query := r.URL.Query()
+	filter := &entity.BlogFilter{
+		Sort:     query.Get("sort"),
+		Cursor:   query.Get("cursor"),
+		Status:   query.Get("status"),
+		Tag:      strings.ToLower(query.Get("tag")),
+		Category: query.Get("category"),
+	}
+
+	switch filter.Status {
+	case "", entity.BlogStatusDraft, entity.BlogStatusScheduled, entity.BlogStatusPublished, entity.BlogStatusArchived:
+	default:
+		return nil, invalidField("status", fmt.Sprintf("must be one of %s, %s, %s or %s", entity.BlogStatusDraft, entity.BlogStatusScheduled, entity.BlogStatusPublished, entity.BlogStatusArchived))
+	}
+
+	switch filter.Sort {
+	case "", entity.BlogSortNewest, entity.BlogSortOldest, entity.BlogSortTitle:
+	default:
+		return nil, invalidField("sort", fmt.Sprintf("must be one of %s, %s or %s", entity.BlogSortNewest, entity.BlogSortOldest, entity.BlogSortTitle))
+	}
+
+	if err := parsePage(r, &filter.Page, &filter.Limit); err != nil {
+		return nil, err
+	}
+	if err := positiveQueryInt(r, "user_id", &filter.UserID); err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// parsePage reads the page and limit query parameters
+func parsePage(r *http.Request, page, limit *int) error {
+	if err := positiveQueryInt(r, "page", page); err != nil {
+		return err
+	}
+	return positiveQueryInt(r, "limit", limit)
+}
+
+// positiveQueryInt reads an optional positive integer query parameter into target
+func positiveQueryInt(r *http.Request, name string, target *int) error {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return invalidField(name, "must be a positive integer")
+	}
+	*target = n
+	return nil
+}
+
+// formTags reads the tags of a blog form, sent either as repeated "tags"
+// fields or as a comma separated list. ok is false when no tags field was sent.
+func formTags(r *http.Request) (tags []string, ok bool) {
+	values, ok := r.MultipartForm.Value["tags"]
+	if !ok {
+		return nil, false
+	}
+
+	tags = []string{}
+	for _, value := range values {
+		tags = append(tags, strings.Split(value, ",")...)
+	}
+	return tags, true
+}
+
+// formCategoryID reads the category of a blog form; an empty value clears
+// the category. ok is false when no category_id field was sent.
+func formCategoryID(r *http.Request) (categoryID *int, ok bool, err error) {
+	values, ok := r.MultipartForm.Value["category_id"]
+	if !ok || values[0] == "" {
+		return nil, ok, nil
+	}
+
+	id, err := strconv.Atoi(values[0])
+	if err != nil {
+		return nil, true, invalidField("category_id", "must be a number")
+	}
+	return &id, true, nil
+}
+
+// formThumbnail returns the thumbnail of a blog form: either a "thumbnail"
+// file, which is validated and stored, or an item of the author's media
+// library picked with "thumbnail_media_id". The path is empty when neither
+// field was sent.
+func (h *BlogHandler) formThumbnail(r *http.Request, userID int) (string, error) {
+	if value := r.FormValue("thumbnail_media_id"); value != "" {
+		mediaID, err := strconv.Atoi(value)
+		if err != nil {
+			return "", invalidField("thumbnail_media_id", "must be a number")
+		}
+		return h.BlogUsecase.MediaThumbnail(r.Context(), mediaID, userID)
+	}
+
+	file, _, err := r.FormFile("thumbnail")
+	if err != nil {
+		return "", nil
+	}
+	defer file.Close()
+
+	// Validate the thumbnail and store it along with its resized variants
+	return h.BlogUsecase.SaveThumbnail(r.Context(), file)
+}
+
+// requireField adds a validation detail when a required value is empty
+func requireField(details []response.FieldError, field, value string) []response.FieldError {
+	if value == "" {
+		details = append(details, response.FieldError{Field: field, Message: "is required"})
+	}
+	return details
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+// pageLink returns the current request URL with one query parameter replaced
+func pageLink(r *http.Request, key, value string) string {
+	query := r.URL.Query()
+	query.Del("page")
+	query.Del("cursor")
+	query.Set(key, value)
+	return r.URL.Path + "?" + query.Encode()
 }
@@ -0,0 +1,159 @@
+package http
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"blog-api/internal/entity"
+	"blog-api/pkg/auth"
+	"blog-api/pkg/response"
+)
+
+func TestGetBlogs(t *testing.T) {
+	api := newTestAPI(t)
+	authorID, author := api.signUp("alice", auth.RoleAuthor)
+	_, reader := api.signUp("bob", auth.RoleUser)
+
+	draft := api.createBlog(authorID, "Draft", "")
+	published := api.createBlog(authorID, "Published", entity.BlogStatusPublished)
+
+	count := func(token string) int {
+		t.Helper()
+		w := api.do("GET", "/blogs", token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+		}
+		var page pageResponse
+		decode(t, w, &page)
+		return page.Total
+	}
+	if n := count(""); n != 1 {
+		t.Errorf("anonymous users list %d blogs, want 1", n)
+	}
+	if n := count(author.AccessToken); n != 2 {
+		t.Errorf("the author lists %d blogs, want 2 including the draft", n)
+	}
+
+	path := "/blogs/" + strconv.Itoa(draft.ID)
+	if w := api.do("GET", path, author.AccessToken, nil); w.Code != http.StatusOK {
+		t.Errorf("the author gets status %d for their draft, want 200", w.Code)
+	}
+	expectError(t, api.do("GET", path, reader.AccessToken, nil), http.StatusNotFound, response.CodeNotFound)
+	expectError(t, api.do("GET", "/blogs/999", "", nil), http.StatusNotFound, response.CodeNotFound)
+
+	env := expectError(t, api.do("GET", "/blogs/first", "", nil), http.StatusBadRequest, response.CodeValidation)
+	if len(env.Error.Details) != 1 || env.Error.Details[0].Field != "id" {
+		t.Errorf("validation details = %+v, want id", env.Error.Details)
+	}
+
+	expectError(t, api.do("GET", "/blogs?cursor=garbage", "", nil), http.StatusBadRequest, response.CodeValidation)
+
+	// A token that is sent has to be valid, even on public routes
+	expectError(t, api.do("GET", "/blogs", "not-a-token", nil), http.StatusUnauthorized, response.CodeUnauthorized)
+
+	w := api.do("GET", "/blogs/by-slug/"+published.Slug, "", nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+}
+
+func TestCreateBlog(t *testing.T) {
+	api := newTestAPI(t)
+	_, author := api.signUp("alice", auth.RoleAuthor)
+	_, reader := api.signUp("bob", auth.RoleUser)
+
+	post := func(token string, fields map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		for name, value := range fields {
+			form.WriteField(name, value)
+		}
+		form.Close()
+
+		req := httptest.NewRequest("POST", APIPrefix+"/blogs", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		api.handler.ServeHTTP(w, req)
+		return w
+	}
+
+	fields := map[string]string{"title": "Hello", "content": "World"}
+	expectError(t, post("", fields), http.StatusUnauthorized, response.CodeUnauthorized)
+	expectError(t, post(reader.AccessToken, fields), http.StatusForbidden, response.CodeForbidden)
+
+	env := expectError(t, post(author.AccessToken, map[string]string{}), http.StatusBadRequest, response.CodeValidation)
+	if len(env.Error.Details) != 2 || env.Error.Details[0].Field != "title" || env.Error.Details[1].Field != "content" {
+		t.Errorf("validation details = %+v, want title and content", env.Error.Details)
+	}
+
+	env = expectError(t, post(author.AccessToken, fields), http.StatusBadRequest, response.CodeValidation)
+	if len(env.Error.Details) != 1 || env.Error.Details[0].Field != "thumbnail" {
+		t.Errorf("validation details = %+v, want thumbnail", env.Error.Details)
+	}
+
+	// Only media of the author's own library can be used as the thumbnail
+	fields["thumbnail_media_id"] = "1"
+	env = expectError(t, post(author.AccessToken, fields), http.StatusBadRequest, response.CodeValidation)
+	if len(env.Error.Details) != 1 || env.Error.Details[0].Field != "thumbnail_media_id" {
+		t.Errorf("validation details = %+v, want thumbnail_media_id", env.Error.Details)
+	}
+
+	w := api.do("POST", "/blogs", author.AccessToken, "{}")
+	expectError(t, w, http.StatusBadRequest, response.CodeBadRequest)
+}
+
+func TestDeleteBlog(t *testing.T) {
+	api := newTestAPI(t)
+	authorID, author := api.signUp("alice", auth.RoleAuthor)
+	_, otherAuthor := api.signUp("carol", auth.RoleAuthor)
+
+	blog := api.createBlog(authorID, "Mine", entity.BlogStatusPublished)
+	path := "/blogs/" + strconv.Itoa(blog.ID)
+
+	expectError(t, api.do("DELETE", path, "", nil), http.StatusUnauthorized, response.CodeUnauthorized)
+	expectError(t, api.do("DELETE", path, otherAuthor.AccessToken, nil), http.StatusForbidden, response.CodeForbidden)
+
+	if w := api.do("DELETE", path, author.AccessToken, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204: %s", w.Code, w.Body)
+	}
+	expectError(t, api.do("DELETE", path, author.AccessToken, nil), http.StatusNotFound, response.CodeNotFound)
+	expectError(t, api.do("GET", path, "", nil), http.StatusNotFound, response.CodeNotFound)
+}
+
+func TestChangeBlogStatus(t *testing.T) {
+	api := newTestAPI(t)
+	authorID, author := api.signUp("alice", auth.RoleAuthor)
+	_, otherAuthor := api.signUp("carol", auth.RoleAuthor)
+
+	blog := api.createBlog(authorID, "Draft", "")
+	path := "/blogs/" + strconv.Itoa(blog.ID)
+
+	expectError(t, api.do("POST", path+"/publish", otherAuthor.AccessToken, nil), http.StatusForbidden, response.CodeForbidden)
+
+	w := api.do("POST", path+"/archive", author.AccessToken, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	var archived blogResponse
+	decode(t, w, &archived)
+	if archived.Status != entity.BlogStatusArchived {
+		t.Errorf("status = %s, want archived", archived.Status)
+	}
+
+	// Archived blogs go back to drafts before they are published again
+	expectError(t, api.do("POST", path+"/publish", author.AccessToken, nil), http.StatusConflict, response.CodeConflict)
+
+	w = api.do("PUT", path+"/comment-mode", author.AccessToken, map[string]string{"comment_mode": "maybe"})
+	env := expectError(t, w, http.StatusBadRequest, response.CodeValidation)
+	if len(env.Error.Details) != 1 || env.Error.Details[0].Field != "comment_mode" {
+		t.Errorf("validation details = %+v, want comment_mode", env.Error.Details)
+	}
+}
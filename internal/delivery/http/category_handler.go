@@ -0,0 +1,96 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"blog-api/internal/entity"
+	"blog-api/internal/usecase"
+	"blog-api/pkg/auth"
+	"blog-api/pkg/middleware"
+	"blog-api/pkg/response"
+
+	"github.com/gorilla/mux"
+)
+
+type CategoryHandler struct {
+	CategoryUsecase usecase.CategoryUsecase
+}
+
+func NewCategoryHandler(r *mux.Router, categoryUsecase usecase.CategoryUsecase, authn *middleware.Auth) {
+	handler := &CategoryHandler{
+		CategoryUsecase: categoryUsecase,
+	}
+
+	// Anyone can browse the category tree
+	r.HandleFunc("/categories", handler.GetCategories).Methods("GET")
+
+	// Authors manage the categories
+	r.Handle("/categories", authn.Permission(auth.PermCategoryManage)(http.HandlerFunc(handler.CreateCategory))).Methods("POST")
+	r.Handle("/categories/{id:[0-9]+}", authn.Permission(auth.PermCategoryManage)(http.HandlerFunc(handler.UpdateCategory))).Methods("PUT")
+	r.Handle("/categories/{id:[0-9]+}", authn.Permission(auth.PermCategoryManage)(http.HandlerFunc(handler.DeleteCategory))).Methods("DELETE")
+}
+
+func (h *CategoryHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.CategoryUsecase.GetTree(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newCategoryResponses(categories))
+}
+
+func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var category entity.Category
+	if err := decodeJSON(r, &category); err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+	category.ID = 0
+
+	if err := h.CategoryUsecase.Create(r.Context(), &category); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	location := fmt.Sprintf("%s/categories/%d", APIPrefix, category.ID)
+	response.Created(w, location, newCategoryResponse(&category))
+}
+
+func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+
+	var category entity.Category
+	if err := decodeJSON(r, &category); err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+	category.ID = id
+
+	if err := h.CategoryUsecase.Update(r.Context(), &category); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newCategoryResponse(&category))
+}
+
+func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+
+	if err := h.CategoryUsecase.Delete(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,29 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"blog-api/pkg/auth"
+	"blog-api/pkg/response"
+)
+
+func TestCreateCategory(t *testing.T) {
+	api := newTestAPI(t)
+	_, author := api.signUp("alice", auth.RoleAuthor)
+	_, reader := api.signUp("bob", auth.RoleUser)
+
+	w := api.do("POST", "/categories", reader.AccessToken, map[string]string{"name": "Travel"})
+	expectError(t, w, http.StatusForbidden, response.CodeForbidden)
+
+	w = api.do("POST", "/categories", author.AccessToken, map[string]string{"name": "Travel"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body)
+	}
+	var category categoryResponse
+	decode(t, w, &category)
+	if want := APIPrefix + "/categories/" + strconv.Itoa(category.ID); w.Header().Get("Location") != want {
+		t.Errorf("Location = %q, want %q", w.Header().Get("Location"), want)
+	}
+}
@@ -0,0 +1,211 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"blog-api/internal/entity"
+	"blog-api/internal/usecase"
+	"blog-api/pkg/auth"
+	"blog-api/pkg/middleware"
+	"blog-api/pkg/response"
+
+	"github.com/gorilla/mux"
+)
+
+type CommentHandler struct {
+	CommentUsecase usecase.CommentUsecase
+}
+
+func NewCommentHandler(r *mux.Router, commentUsecase usecase.CommentUsecase, authn *middleware.Auth) {
+	handler := &CommentHandler{
+		CommentUsecase: commentUsecase,
+	}
+
+	// Authors moderate the comments on their blogs; admins on every blog
+	r.Handle("/comments/pending", authn.Permission(auth.PermCommentModerate)(http.HandlerFunc(handler.GetPendingComments))).Methods("GET")
+	r.Handle("/comments/{id}/approve", authn.Permission(auth.PermCommentModerate)(handler.ModerateComment(entity.CommentStatusApproved))).Methods("POST")
+	r.Handle("/comments/{id}/reject", authn.Permission(auth.PermCommentModerate)(handler.ModerateComment(entity.CommentStatusRejected))).Methods("POST")
+
+	// Anyone can read the comments on visible blogs
+	r.Handle("/blogs/{id}/comments", authn.Optional(http.HandlerFunc(handler.GetBlogComments))).Methods("GET")
+	r.Handle("/comments/{id}", authn.Optional(http.HandlerFunc(handler.GetComment))).Methods("GET")
+
+	// Signed in users can comment and manage their own comments
+	r.Handle("/comments/{blogID}", authn.Permission(auth.PermCommentWrite)(http.HandlerFunc(handler.CreateComment))).Methods("POST")
+	r.Handle("/comments/{id}", authn.Permission(auth.PermCommentWrite)(http.HandlerFunc(handler.UpdateComment))).Methods("PUT")
+	r.Handle("/comments/{id}", authn.Permission(auth.PermCommentWrite)(http.HandlerFunc(handler.DeleteComment))).Methods("DELETE")
+}
+
+func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	var comment entity.Comment
+	if err := decodeJSON(r, &comment); err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+
+	blogID, err := pathID(r, "blogID")
+	if err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+	comment.BlogID = blogID
+
+	// Assuming user ID is extracted from context or JWT token
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		writeUnauthorized(w, r)
+		return
+	}
+	comment.UserID = userID
+
+	if err := h.CommentUsecase.Create(r.Context(), &comment); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	location := fmt.Sprintf("%s/comments/%d", APIPrefix, comment.ID)
+	response.Created(w, location, newCommentResponse(&comment))
+}
+
+func (h *CommentHandler) GetBlogComments(w http.ResponseWriter, r *http.Request) {
+	blogID, err := pathID(r, "id")
+	if err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+
+	filter := &entity.CommentFilter{
+		BlogID:   blogID,
+		ViewerID: viewerID(r),
+		Flat:     r.URL.Query().Get("format") == "flat",
+	}
+	if err := parsePage(r, &filter.Page, &filter.Limit); err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+
+	page, err := h.CommentUsecase.GetByBlog(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	items := newCommentResponses(page.Comments)
+	response.JSON(w, http.StatusOK, newPageResponse(r, items, page.Total, filter.Page, filter.Limit, page.HasMore))
+}
+
+func (h *CommentHandler) GetPendingComments(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	filter := &entity.ModerationFilter{}
+	if err := parsePage(r, &filter.Page, &filter.Limit); err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+
+	page, err := h.CommentUsecase.GetPending(r.Context(), principal, filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	items := newCommentResponses(page.Comments)
+	response.JSON(w, http.StatusOK, newPageResponse(r, items, page.Total, filter.Page, filter.Limit, page.HasMore))
+}
+
+// ModerateComment returns a handler that approves or rejects a comment
+func (h *CommentHandler) ModerateComment(status string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := pathID(r, "id")
+		if err != nil {
+			writeInputError(w, r, err)
+			return
+		}
+
+		principal, ok := auth.FromContext(r.Context())
+		if !ok {
+			writeUnauthorized(w, r)
+			return
+		}
+
+		comment, err := h.CommentUsecase.Moderate(r.Context(), id, principal, status)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		response.JSON(w, http.StatusOK, newCommentResponse(comment))
+	})
+}
+
+func (h *CommentHandler) GetComment(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+
+	comment, err := h.CommentUsecase.GetByID(r.Context(), id, viewerID(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newCommentResponse(comment))
+}
+
+func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	comment, err := h.CommentUsecase.Update(r.Context(), id, userID, req.Content)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newCommentResponse(comment))
+}
+
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	if err := h.CommentUsecase.Delete(r.Context(), id, principal); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
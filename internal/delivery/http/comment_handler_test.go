@@ -0,0 +1,104 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"blog-api/internal/entity"
+	"blog-api/pkg/auth"
+	"blog-api/pkg/response"
+)
+
+func TestCommentModeration(t *testing.T) {
+	api := newTestAPI(t)
+	authorID, author := api.signUp("alice", auth.RoleAuthor)
+	_, otherAuthor := api.signUp("carol", auth.RoleAuthor)
+	_, reader := api.signUp("bob", auth.RoleUser)
+
+	blog := api.createBlog(authorID, "Moderated", entity.BlogStatusPublished)
+	if _, err := api.blogs.SetCommentMode(context.Background(), blog.ID, &auth.Principal{UserID: authorID}, entity.CommentModeModerated); err != nil {
+		t.Fatal(err)
+	}
+	path := "/comments/" + strconv.Itoa(blog.ID)
+
+	expectError(t, api.do("POST", path, "", map[string]string{"content": "Hi"}), http.StatusUnauthorized, response.CodeUnauthorized)
+
+	env := expectError(t, api.do("POST", path, reader.AccessToken, map[string]string{"content": "  "}), http.StatusBadRequest, response.CodeValidation)
+	if len(env.Error.Details) != 1 || env.Error.Details[0].Field != "content" {
+		t.Errorf("validation details = %+v, want content", env.Error.Details)
+	}
+
+	expectError(t, api.do("POST", "/comments/999", reader.AccessToken, map[string]string{"content": "Hi"}), http.StatusNotFound, response.CodeNotFound)
+
+	w := api.do("POST", path, reader.AccessToken, map[string]string{"content": "First!"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body)
+	}
+	var comment commentResponse
+	decode(t, w, &comment)
+	if comment.Status != entity.CommentStatusPending {
+		t.Errorf("status = %s, want pending", comment.Status)
+	}
+
+	commentPath := "/comments/" + strconv.Itoa(comment.ID)
+	expectError(t, api.do("GET", commentPath, "", nil), http.StatusNotFound, response.CodeNotFound)
+
+	// Readers lack the moderation permission, other authors do not own the blog
+	expectError(t, api.do("GET", "/comments/pending", reader.AccessToken, nil), http.StatusForbidden, response.CodeForbidden)
+	expectError(t, api.do("POST", commentPath+"/approve", reader.AccessToken, nil), http.StatusForbidden, response.CodeForbidden)
+	expectError(t, api.do("POST", commentPath+"/approve", otherAuthor.AccessToken, nil), http.StatusForbidden, response.CodeForbidden)
+
+	pending := func(token string) int {
+		t.Helper()
+		w := api.do("GET", "/comments/pending", token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+		}
+		var page pageResponse
+		decode(t, w, &page)
+		return page.Total
+	}
+	if n := pending(author.AccessToken); n != 1 {
+		t.Errorf("the blog's author has %d pending comments, want 1", n)
+	}
+	if n := pending(otherAuthor.AccessToken); n != 0 {
+		t.Errorf("another author has %d pending comments, want 0", n)
+	}
+
+	if w := api.do("POST", commentPath+"/approve", author.AccessToken, nil); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	if w := api.do("GET", commentPath, "", nil); w.Code != http.StatusOK {
+		t.Errorf("status = %d for the approved comment, want 200", w.Code)
+	}
+	if n := pending(author.AccessToken); n != 0 {
+		t.Errorf("%d pending comments after approval, want 0", n)
+	}
+}
+
+func TestDeleteComment(t *testing.T) {
+	api := newTestAPI(t)
+	authorID, author := api.signUp("alice", auth.RoleAuthor)
+	_, reader := api.signUp("bob", auth.RoleUser)
+	_, otherReader := api.signUp("dave", auth.RoleUser)
+
+	blog := api.createBlog(authorID, "Open", entity.BlogStatusPublished)
+	w := api.do("POST", "/comments/"+strconv.Itoa(blog.ID), reader.AccessToken, map[string]string{"content": "Hello"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body)
+	}
+	var comment commentResponse
+	decode(t, w, &comment)
+	path := "/comments/" + strconv.Itoa(comment.ID)
+
+	expectError(t, api.do("PUT", path, otherReader.AccessToken, map[string]string{"content": "Mine now"}), http.StatusForbidden, response.CodeForbidden)
+	expectError(t, api.do("DELETE", path, otherReader.AccessToken, nil), http.StatusForbidden, response.CodeForbidden)
+
+	// The blog's author may remove comments on their blog
+	if w := api.do("DELETE", path, author.AccessToken, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204: %s", w.Code, w.Body)
+	}
+	expectError(t, api.do("GET", path, "", nil), http.StatusNotFound, response.CodeNotFound)
+}
@@ -0,0 +1,327 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"blog-api/internal/entity"
+)
+
+// Response DTOs decouple the JSON API from the entity structs, so storage
+// details such as file paths never leak and field names stay snake_case.
+
+type imageResponse struct {
+	URL      string                 `json:"url"`
+	Variants []imageVariantResponse `json:"variants"`
+}
+
+type imageVariantResponse struct {
+	Width int    `json:"width"`
+	URL   string `json:"url"`
+}
+
+type blogResponse struct {
+	ID          int            `json:"id"`
+	Title       string         `json:"title"`
+	Slug        string         `json:"slug"`
+	Content     string         `json:"content"`
+	UserID      int            `json:"user_id"`
+	Thumbnail   *imageResponse `json:"thumbnail"`
+	CategoryID  *int           `json:"category_id"`
+	Tags        []string       `json:"tags"`
+	Status      string         `json:"status"`
+	CommentMode string         `json:"comment_mode"`
+	PublishAt   *time.Time     `json:"publish_at"`
+	PublishedAt *time.Time     `json:"published_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+type searchResultResponse struct {
+	blogResponse
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet"`
+}
+
+type tagResponse struct {
+	Name      string `json:"name"`
+	PostCount int    `json:"post_count"`
+}
+
+type categoryResponse struct {
+	ID        int                 `json:"id"`
+	Name      string              `json:"name"`
+	Slug      string              `json:"slug"`
+	ParentID  *int                `json:"parent_id"`
+	PostCount int                 `json:"post_count"`
+	Children  []*categoryResponse `json:"children,omitempty"`
+}
+
+type commentResponse struct {
+	ID        int                `json:"id"`
+	Content   string             `json:"content"`
+	UserID    int                `json:"user_id"`
+	BlogID    int                `json:"blog_id"`
+	ParentID  *int               `json:"parent_id"`
+	Depth     int                `json:"depth"`
+	Status    string             `json:"status"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+	Replies   []*commentResponse `json:"replies,omitempty"`
+}
+
+type revisionResponse struct {
+	Revision  int            `json:"revision"`
+	Title     string         `json:"title"`
+	Content   string         `json:"content"`
+	Thumbnail *imageResponse `json:"thumbnail"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+type diffLineResponse struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+type revisionDiffResponse struct {
+	From      int                `json:"from"`
+	To        int                `json:"to"`
+	Title     []diffLineResponse `json:"title"`
+	Content   []diffLineResponse `json:"content"`
+	Thumbnail []diffLineResponse `json:"thumbnail"`
+}
+
+type mediaResponse struct {
+	ID          int                    `json:"id"`
+	URL         string                 `json:"url"`
+	Variants    []imageVariantResponse `json:"variants"`
+	ContentType string                 `json:"content_type"`
+	Width       int                    `json:"width"`
+	Height      int                    `json:"height"`
+	Size        int                    `json:"size"`
+	CreatedAt   time.Time              `json:"created_at"`
+}
+
+type userResponse struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+type loginResponse struct {
+	tokenResponse
+	User *userResponse `json:"user"`
+}
+
+// pageResponse is the envelope of paginated lists. Next and Prev link to
+// the adjacent pages; NextCursor is only set for cursor paginated lists.
+type pageResponse struct {
+	Data       interface{} `json:"data"`
+	Total      int         `json:"total"`
+	Page       int         `json:"page,omitempty"`
+	Limit      int         `json:"limit"`
+	Next       *string     `json:"next"`
+	Prev       *string     `json:"prev"`
+	NextCursor *string     `json:"next_cursor,omitempty"`
+}
+
+// newPageResponse builds the envelope of an offset paginated list
+func newPageResponse(r *http.Request, data interface{}, total, page, limit int, hasMore bool) *pageResponse {
+	resp := &pageResponse{Data: data, Total: total, Page: page, Limit: limit}
+	if hasMore {
+		resp.Next = stringPtr(pageLink(r, "page", strconv.Itoa(page+1)))
+	}
+	if page > 1 {
+		resp.Prev = stringPtr(pageLink(r, "page", strconv.Itoa(page-1)))
+	}
+	return resp
+}
+
+// newImageResponse returns the URLs a stored image is served from, or nil
+// when there is no image
+func newImageResponse(r *http.Request, baseURL, stored string) *imageResponse {
+	if stored == "" {
+		return nil
+	}
+	return &imageResponse{
+		URL:      mediaURL(r, baseURL, stored),
+		Variants: imageVariants(r, baseURL, stored),
+	}
+}
+
+func newBlogResponse(r *http.Request, baseURL string, blog *entity.Blog) *blogResponse {
+	tags := blog.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return &blogResponse{
+		ID:          blog.ID,
+		Title:       blog.Title,
+		Slug:        blog.Slug,
+		Content:     blog.Content,
+		UserID:      blog.UserID,
+		Thumbnail:   newImageResponse(r, baseURL, blog.Thumbnail),
+		CategoryID:  blog.CategoryID,
+		Tags:        tags,
+		Status:      blog.Status,
+		CommentMode: blog.CommentMode,
+		PublishAt:   blog.PublishAt,
+		PublishedAt: blog.PublishedAt,
+		CreatedAt:   blog.CreatedAt,
+	}
+}
+
+func newBlogResponses(r *http.Request, baseURL string, blogs []*entity.Blog) []*blogResponse {
+	resp := make([]*blogResponse, 0, len(blogs))
+	for _, blog := range blogs {
+		resp = append(resp, newBlogResponse(r, baseURL, blog))
+	}
+	return resp
+}
+
+func newSearchResultResponses(r *http.Request, baseURL string, results []*entity.BlogSearchResult) []*searchResultResponse {
+	resp := make([]*searchResultResponse, 0, len(results))
+	for _, result := range results {
+		resp = append(resp, &searchResultResponse{
+			blogResponse: *newBlogResponse(r, baseURL, result.Blog),
+			Score:        result.Score,
+			Snippet:      result.Snippet,
+		})
+	}
+	return resp
+}
+
+func newTagResponses(tags []*entity.Tag) []*tagResponse {
+	resp := make([]*tagResponse, 0, len(tags))
+	for _, tag := range tags {
+		resp = append(resp, &tagResponse{Name: tag.Name, PostCount: tag.PostCount})
+	}
+	return resp
+}
+
+func newCategoryResponse(category *entity.Category) *categoryResponse {
+	resp := &categoryResponse{
+		ID:        category.ID,
+		Name:      category.Name,
+		Slug:      category.Slug,
+		ParentID:  category.ParentID,
+		PostCount: category.PostCount,
+	}
+	for _, child := range category.Children {
+		resp.Children = append(resp.Children, newCategoryResponse(child))
+	}
+	return resp
+}
+
+func newCategoryResponses(categories []*entity.Category) []*categoryResponse {
+	resp := make([]*categoryResponse, 0, len(categories))
+	for _, category := range categories {
+		resp = append(resp, newCategoryResponse(category))
+	}
+	return resp
+}
+
+func newCommentResponse(comment *entity.Comment) *commentResponse {
+	resp := &commentResponse{
+		ID:        comment.ID,
+		Content:   comment.Content,
+		UserID:    comment.UserID,
+		BlogID:    comment.BlogID,
+		ParentID:  comment.ParentID,
+		Depth:     comment.Depth,
+		Status:    comment.Status,
+		CreatedAt: comment.CreatedAt,
+		UpdatedAt: comment.UpdatedAt,
+	}
+	for _, reply := range comment.Replies {
+		resp.Replies = append(resp.Replies, newCommentResponse(reply))
+	}
+	return resp
+}
+
+func newCommentResponses(comments []*entity.Comment) []*commentResponse {
+	resp := make([]*commentResponse, 0, len(comments))
+	for _, comment := range comments {
+		resp = append(resp, newCommentResponse(comment))
+	}
+	return resp
+}
+
+func newRevisionResponses(r *http.Request, baseURL string, revisions []*entity.BlogRevision) []*revisionResponse {
+	resp := make([]*revisionResponse, 0, len(revisions))
+	for _, revision := range revisions {
+		resp = append(resp, &revisionResponse{
+			Revision:  revision.Revision,
+			Title:     revision.Title,
+			Content:   revision.Content,
+			Thumbnail: newImageResponse(r, baseURL, revision.Thumbnail),
+			CreatedAt: revision.CreatedAt,
+		})
+	}
+	return resp
+}
+
+func newRevisionDiffResponse(diff *entity.RevisionDiff) *revisionDiffResponse {
+	return &revisionDiffResponse{
+		From:      diff.From,
+		To:        diff.To,
+		Title:     newDiffLineResponses(diff.Title),
+		Content:   newDiffLineResponses(diff.Content),
+		Thumbnail: newDiffLineResponses(diff.Thumbnail),
+	}
+}
+
+func newDiffLineResponses(lines []entity.DiffLine) []diffLineResponse {
+	resp := make([]diffLineResponse, 0, len(lines))
+	for _, line := range lines {
+		resp = append(resp, diffLineResponse{Op: line.Op, Text: line.Text})
+	}
+	return resp
+}
+
+func newMediaResponse(r *http.Request, baseURL string, media *entity.Media) *mediaResponse {
+	return &mediaResponse{
+		ID:          media.ID,
+		URL:         mediaURL(r, baseURL, media.Path),
+		Variants:    imageVariants(r, baseURL, media.Path),
+		ContentType: media.ContentType,
+		Width:       media.Width,
+		Height:      media.Height,
+		Size:        media.Size,
+		CreatedAt:   media.CreatedAt,
+	}
+}
+
+func newMediaResponses(r *http.Request, baseURL string, items []*entity.Media) []*mediaResponse {
+	resp := make([]*mediaResponse, 0, len(items))
+	for _, media := range items {
+		resp = append(resp, newMediaResponse(r, baseURL, media))
+	}
+	return resp
+}
+
+func newUserResponse(user *entity.User) *userResponse {
+	return &userResponse{
+		ID:       user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		Role:     user.Role,
+	}
+}
+
+func newTokenResponse(tokens *entity.TokenPair) *tokenResponse {
+	return &tokenResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(tokens.ExpiresIn.Seconds()),
+	}
+}
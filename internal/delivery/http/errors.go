@@ -0,0 +1,127 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"blog-api/internal/usecase"
+	"blog-api/pkg/response"
+
+	"github.com/gorilla/mux"
+)
+
+// fieldError is a validation failure of a single request field
+type fieldError struct {
+	field   string
+	message string
+}
+
+func (e *fieldError) Error() string {
+	return e.field + " " + e.message
+}
+
+func invalidField(field, message string) error {
+	return &fieldError{field: field, message: message}
+}
+
+// writeInputError writes a 400 response for a malformed request, with
+// field details when the error names the offending field
+func writeInputError(w http.ResponseWriter, r *http.Request, err error) {
+	var field *fieldError
+	if errors.As(err, &field) {
+		writeValidationError(w, r, response.FieldError{Field: field.field, Message: field.message})
+		return
+	}
+	response.Error(w, r, http.StatusBadRequest, response.CodeBadRequest, err.Error())
+}
+
+// writeError translates an error to a response by its kind. Domain errors
+// of the usecases and invalid request fields get a matching status; anything
+// else is an internal error whose details are only logged. Running out of
+// the query timeout is reported as 503.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		response.Error(w, r, http.StatusServiceUnavailable, response.CodeTimeout, "The request took too long, please try again")
+		return
+	case errors.Is(err, context.Canceled):
+		// The client went away, so nobody is left to read a response
+		return
+	}
+
+	var field *fieldError
+	if errors.As(err, &field) {
+		writeInputError(w, r, err)
+		return
+	}
+
+	var domainErr *usecase.Error
+	if !errors.As(err, &domainErr) {
+		response.Internal(w, r, err)
+		return
+	}
+
+	message := err.Error()
+	switch domainErr.Kind {
+	case usecase.ErrValidation:
+		var details []response.FieldError
+		if domainErr.Field != "" {
+			details = append(details, response.FieldError{Field: domainErr.Field, Message: message})
+		}
+		response.Error(w, r, http.StatusBadRequest, response.CodeValidation, message, details...)
+	case usecase.ErrNotFound:
+		response.Error(w, r, http.StatusNotFound, response.CodeNotFound, message)
+	case usecase.ErrConflict:
+		response.Error(w, r, http.StatusConflict, response.CodeConflict, message)
+	case usecase.ErrForbidden:
+		response.Error(w, r, http.StatusForbidden, response.CodeForbidden, message)
+	case usecase.ErrUnauthorized:
+		response.Error(w, r, http.StatusUnauthorized, response.CodeUnauthorized, message)
+	default:
+		response.Internal(w, r, err)
+	}
+}
+
+// writeValidationError writes a 400 response listing the rejected fields
+func writeValidationError(w http.ResponseWriter, r *http.Request, details ...response.FieldError) {
+	message := "Invalid request"
+	if len(details) == 1 {
+		message = details[0].Field + " " + details[0].Message
+	}
+	response.Error(w, r, http.StatusBadRequest, response.CodeValidation, message, details...)
+}
+
+// writeUnauthorized writes a 401 response for requests missing a signed in user
+func writeUnauthorized(w http.ResponseWriter, r *http.Request) {
+	response.Error(w, r, http.StatusUnauthorized, response.CodeUnauthorized, "Authentication required")
+}
+
+// pathID reads a numeric path variable
+func pathID(r *http.Request, name string) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)[name])
+	if err != nil {
+		return 0, invalidField(name, "must be a number")
+	}
+	return id, nil
+}
+
+// decodeJSON reads the JSON request body into v
+func decodeJSON(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return fmt.Errorf("request body must be valid JSON: %v", err)
+	}
+	return nil
+}
+
+// notFoundHandler and methodNotAllowedHandler answer unknown routes with the error envelope
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	response.Error(w, r, http.StatusNotFound, response.CodeNotFound, "No route matches "+r.URL.Path)
+}
+
+func methodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	response.Error(w, r, http.StatusMethodNotAllowed, response.CodeMethodNotAllowed, r.Method+" is not allowed on "+r.URL.Path)
+}
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"blog-api/pkg/jwt"
+	"blog-api/pkg/response"
+
+	"github.com/gorilla/mux"
+)
+
+// jwksMaxAge is how long verifiers may cache the key set
+const jwksMaxAge = 5 * time.Minute
+
+type JWKSHandler struct {
+	keys *jwt.KeySet
+}
+
+// NewJWKSHandler serves the public keys of access tokens. It belongs on the
+// root router, as the well-known path is not versioned.
+func NewJWKSHandler(r *mux.Router, keys *jwt.KeySet) {
+	handler := &JWKSHandler{keys: keys}
+
+	r.HandleFunc("/.well-known/jwks.json", handler.GetJWKS).Methods("GET")
+}
+
+func (h *JWKSHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(jwksMaxAge.Seconds())))
+	response.JSON(w, http.StatusOK, h.keys.JWKS(time.Now()))
+}
@@ -0,0 +1,230 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"blog-api/internal/entity"
+	"blog-api/internal/usecase"
+	"blog-api/pkg/auth"
+	"blog-api/pkg/middleware"
+	"blog-api/pkg/response"
+	"blog-api/pkg/storage"
+	"blog-api/pkg/upload"
+
+	"github.com/gorilla/mux"
+)
+
+// hashedName matches content-addressed upload names and the names of their
+// variants, which never change content
+var hashedName = regexp.MustCompile(`^([0-9a-f]{64}(?:-[0-9]+)?)\.[a-z0-9]+$`)
+
+type MediaHandler struct {
+	MediaUsecase usecase.MediaUsecase
+	Storage      storage.Storage
+	baseURL      string
+	// signedURLTTL is how long redirects to remote storage stay valid
+	signedURLTTL time.Duration
+}
+
+func NewMediaHandler(r *mux.Router, mediaUsecase usecase.MediaUsecase, store storage.Storage, authn *middleware.Auth, baseURL string, signedURLTTL time.Duration) {
+	handler := &MediaHandler{
+		MediaUsecase: mediaUsecase,
+		Storage:      store,
+		baseURL:      baseURL,
+		signedURLTTL: signedURLTTL,
+	}
+
+	// Anyone can fetch stored files
+	r.HandleFunc("/"+upload.Dir+"/{name}", handler.ServeMedia).Methods("GET", "HEAD")
+
+	// Authors manage their media library. Items are used as a blog's
+	// thumbnail through thumbnail_media_id, or embedded in content by URL.
+	r.Handle("/media", authn.Permission(auth.PermMediaManage)(http.HandlerFunc(handler.UploadMedia))).Methods("POST")
+	r.Handle("/media", authn.Permission(auth.PermMediaManage)(http.HandlerFunc(handler.GetMedia))).Methods("GET")
+	r.Handle("/media/{id:[0-9]+}", authn.Permission(auth.PermMediaManage)(http.HandlerFunc(handler.GetMediaByID))).Methods("GET")
+	r.Handle("/media/{id:[0-9]+}", authn.Permission(auth.PermMediaManage)(http.HandlerFunc(handler.DeleteMedia))).Methods("DELETE")
+}
+
+func (h *MediaHandler) UploadMedia(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		response.Error(w, r, http.StatusBadRequest, response.CodeBadRequest, "Request body must be a multipart form")
+		return
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeValidationError(w, r, response.FieldError{Field: "file", Message: "is required"})
+		return
+	}
+	defer file.Close()
+
+	media, err := h.MediaUsecase.Upload(r.Context(), userID, file)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := newMediaResponse(r, h.baseURL, media)
+	response.Created(w, resp.URL, resp)
+}
+
+func (h *MediaHandler) GetMedia(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	filter := &entity.MediaFilter{UserID: userID}
+	if err := parsePage(r, &filter.Page, &filter.Limit); err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+
+	page, err := h.MediaUsecase.GetByUser(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	items := newMediaResponses(r, h.baseURL, page.Media)
+	response.JSON(w, http.StatusOK, newPageResponse(r, items, page.Total, filter.Page, filter.Limit, page.HasMore))
+}
+
+func (h *MediaHandler) GetMediaByID(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	id, err := pathID(r, "id")
+	if err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+
+	media, err := h.MediaUsecase.GetByID(r.Context(), id, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newMediaResponse(r, h.baseURL, media))
+}
+
+func (h *MediaHandler) DeleteMedia(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	id, err := pathID(r, "id")
+	if err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+
+	if err := h.MediaUsecase.Delete(r.Context(), id, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ServeMedia serves a stored upload. Local files are streamed with
+// http.ServeContent, which takes care of Content-Type, Last-Modified,
+// conditional requests and Range requests. Remote objects are served by
+// redirecting to a signed URL, so the storage service handles those.
+func (h *MediaHandler) ServeMedia(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	// Temporary files start with "." and must never be exposed
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		response.Error(w, r, http.StatusNotFound, response.CodeNotFound, "File not found")
+		return
+	}
+	key := upload.Dir + "/" + name
+
+	opener, ok := h.Storage.(storage.Opener)
+	if !ok {
+		signedURL, err := h.Storage.SignedURL(r.Context(), key, h.signedURLTTL)
+		if err != nil {
+			response.Internal(w, r, err)
+			return
+		}
+		// The redirect may be cached for a little less than the URL stays valid
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(h.signedURLTTL.Seconds()*0.9)))
+		http.Redirect(w, r, signedURL, http.StatusFound)
+		return
+	}
+
+	object, err := opener.Open(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			response.Error(w, r, http.StatusNotFound, response.CodeNotFound, "File not found")
+			return
+		}
+		response.Internal(w, r, err)
+		return
+	}
+	defer object.Close()
+
+	if m := hashedName.FindStringSubmatch(name); m != nil {
+		// The name is the content hash, so the file can be cached forever
+		w.Header().Set("ETag", `"`+m[1]+`"`)
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		// Legacy names may be overwritten in place, so clients must revalidate
+		w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, object.ModTime.UnixNano(), object.Size))
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(w, r, name, object.ModTime, object)
+}
+
+// mediaURL turns a stored upload path into an absolute URL. When baseURL is
+// empty the origin is taken from the request.
+func mediaURL(r *http.Request, baseURL, stored string) string {
+	if stored == "" {
+		return ""
+	}
+
+	if baseURL == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		baseURL = scheme + "://" + r.Host
+	}
+	return baseURL + APIPrefix + "/" + path.Clean(filepath.ToSlash(stored))
+}
+
+// imageVariants returns the URLs of the resized variants of a stored image
+func imageVariants(r *http.Request, baseURL, stored string) []imageVariantResponse {
+	variants := []imageVariantResponse{}
+	for _, variant := range upload.Variants(stored) {
+		variants = append(variants, imageVariantResponse{
+			Width: variant.Width,
+			URL:   mediaURL(r, baseURL, variant.Key),
+		})
+	}
+	return variants
+}
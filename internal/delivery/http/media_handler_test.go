@@ -0,0 +1,56 @@
+package http
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"blog-api/pkg/auth"
+	"blog-api/pkg/response"
+)
+
+func TestUploadMedia(t *testing.T) {
+	api := newTestAPI(t)
+	_, author := api.signUp("alice", auth.RoleAuthor)
+	_, reader := api.signUp("bob", auth.RoleUser)
+
+	upload := func(token string, data []byte) *httptest.ResponseRecorder {
+		t.Helper()
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("file", "image.png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(data)
+		form.Close()
+
+		req := httptest.NewRequest("POST", APIPrefix+"/media", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		api.handler.ServeHTTP(w, req)
+		return w
+	}
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+
+	expectError(t, upload(reader.AccessToken, img.Bytes()), http.StatusForbidden, response.CodeForbidden)
+
+	w := upload(author.AccessToken, img.Bytes())
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body)
+	}
+	var media mediaResponse
+	decode(t, w, &media)
+	if media.URL == "" || w.Header().Get("Location") != media.URL {
+		t.Errorf("Location = %q, want the media URL %q", w.Header().Get("Location"), media.URL)
+	}
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"net/http"
+
+	"blog-api/pkg/requestid"
+
+	"github.com/gorilla/mux"
+)
+
+// APIPrefix is the path every route of the current API version is mounted under
+const APIPrefix = "/api/v1"
+
+// NewRouter returns the root router, which tags requests with an ID and
+// answers unknown routes with the error envelope, along with the subrouter
+// the API routes are registered on
+func NewRouter() (root, api *mux.Router) {
+	methodNotAllowed := requestid.Middleware(http.HandlerFunc(methodNotAllowedHandler))
+
+	root = mux.NewRouter()
+	root.Use(requestid.Middleware)
+	root.NotFoundHandler = requestid.Middleware(http.HandlerFunc(notFoundHandler))
+	root.MethodNotAllowedHandler = methodNotAllowed
+
+	api = root.PathPrefix(APIPrefix).Subrouter()
+	api.MethodNotAllowedHandler = methodNotAllowed
+	api.NotFoundHandler = requestid.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if methodMismatch(api, r) {
+			methodNotAllowedHandler(w, r)
+			return
+		}
+		notFoundHandler(w, r)
+	}))
+	return root, api
+}
+
+// methodMismatch reports whether a route matches the request path but not
+// its method. Subrouters lose track of such mismatches when a later route
+// shares their prefix, so they are looked up route by route.
+func methodMismatch(router *mux.Router, r *http.Request) bool {
+	mismatch := false
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		var match mux.RouteMatch
+		if !route.Match(r, &match) && match.MatchErr == mux.ErrMethodMismatch {
+			mismatch = true
+			return mux.SkipRouter
+		}
+		return nil
+	})
+	return mismatch
+}
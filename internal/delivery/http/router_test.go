@@ -0,0 +1,191 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"blog-api/internal/entity"
+	"blog-api/internal/repository/memory"
+	"blog-api/internal/usecase"
+	"blog-api/pkg/jwt"
+	"blog-api/pkg/middleware"
+	"blog-api/pkg/requestid"
+	"blog-api/pkg/response"
+	"blog-api/pkg/storage"
+)
+
+const testBaseURL = "http://localhost:8080"
+
+// testAPI is the API wired to in-memory repositories, as cmd/api wires it to MySQL
+type testAPI struct {
+	t        *testing.T
+	handler  http.Handler
+	users    usecase.UserUsecase
+	blogs    usecase.BlogUsecase
+	comments usecase.CommentUsecase
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	db := memory.NewDB()
+	store := storage.NewLocal(t.TempDir(), testBaseURL+APIPrefix)
+
+	key, err := jwt.GenerateKey(jwt.AlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := jwt.NewKeySet()
+	keys.Replace([]*jwt.Key{key})
+
+	signer := jwt.NewSigner(keys, "blog-api", "blog-api")
+	users := usecase.NewUserUsecase(memory.NewUserRepository(db), memory.NewTokenRepository(db), signer, time.Minute, time.Hour)
+	authn := middleware.NewAuth(jwt.NewVerifier(keys, "blog-api", "blog-api", users))
+
+	blogRepo := memory.NewBlogRepository(db)
+	categoryRepo := memory.NewCategoryRepository(db)
+	mediaRepo := memory.NewMediaRepository(db)
+	blogs := usecase.NewBlogUsecase(blogRepo, categoryRepo, mediaRepo, store)
+	comments := usecase.NewCommentUsecase(memory.NewCommentRepository(db), blogRepo)
+
+	r, api := NewRouter()
+	NewJWKSHandler(r, keys)
+	NewUserHandler(api, users, authn)
+	NewBlogHandler(api, blogs, authn, testBaseURL)
+	NewCategoryHandler(api, usecase.NewCategoryUsecase(categoryRepo), authn)
+	NewCommentHandler(api, comments, authn)
+	NewMediaHandler(api, usecase.NewMediaUsecase(mediaRepo, store), store, authn, testBaseURL, time.Minute)
+
+	return &testAPI{t: t, handler: r, users: users, blogs: blogs, comments: comments}
+}
+
+// do sends a request with an optional JSON body and bearer token and
+// returns the recorded response
+func (a *testAPI) do(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	a.t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if s, ok := body.(string); ok {
+			buf.WriteString(s)
+		} else if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			a.t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, APIPrefix+path, &buf)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	a.handler.ServeHTTP(w, req)
+	return w
+}
+
+// signUp registers a user through the API and returns their tokens
+func (a *testAPI) signUp(username, role string) (userID int, tokens tokenResponse) {
+	a.t.Helper()
+	credentials := map[string]string{"username": username, "email": username + "@example.com", "password": "password123", "role": role}
+	w := a.do("POST", "/register", "", credentials)
+	if w.Code != http.StatusCreated {
+		a.t.Fatalf("register %s: status %d: %s", username, w.Code, w.Body)
+	}
+	var user userResponse
+	decode(a.t, w, &user)
+
+	w = a.do("POST", "/login", "", credentials)
+	if w.Code != http.StatusOK {
+		a.t.Fatalf("login %s: status %d: %s", username, w.Code, w.Body)
+	}
+	decode(a.t, w, &tokens)
+	return user.ID, tokens
+}
+
+// createBlog stores a blog directly, skipping the thumbnail upload
+func (a *testAPI) createBlog(userID int, title, status string) *entity.Blog {
+	a.t.Helper()
+	blog := &entity.Blog{Title: title, Content: "Content of " + title, UserID: userID, Status: status, Thumbnail: "uploads/thumbnail.png"}
+	if err := a.blogs.Create(context.Background(), blog); err != nil {
+		a.t.Fatal(err)
+	}
+	return blog
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding %q: %v", w.Body, err)
+	}
+}
+
+type errorEnvelope struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Details []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"details"`
+		RequestID string `json:"request_id"`
+	} `json:"error"`
+}
+
+// expectError checks the status of a response and that its body is the
+// error envelope with the given code, returning the envelope
+func expectError(t *testing.T, w *httptest.ResponseRecorder, status int, code string) errorEnvelope {
+	t.Helper()
+	var env errorEnvelope
+	if w.Code != status {
+		t.Errorf("status = %d, want %d: %s", w.Code, status, w.Body)
+		return env
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	decode(t, w, &env)
+	if env.Error.Code != code {
+		t.Errorf("error code = %q, want %q", env.Error.Code, code)
+	}
+	if env.Error.Message == "" {
+		t.Error("error envelope without a message")
+	}
+	if env.Error.RequestID == "" || env.Error.RequestID != w.Header().Get(requestid.Header) {
+		t.Errorf("error request_id = %q, response %s = %q", env.Error.RequestID, requestid.Header, w.Header().Get(requestid.Header))
+	}
+	return env
+}
+
+func TestUnknownRoutes(t *testing.T) {
+	api := newTestAPI(t)
+
+	expectError(t, api.do("GET", "/nothing-here", "", nil), http.StatusNotFound, response.CodeNotFound)
+	expectError(t, api.do("PATCH", "/blogs", "", nil), http.StatusMethodNotAllowed, response.CodeMethodNotAllowed)
+
+	w := httptest.NewRecorder()
+	api.handler.ServeHTTP(w, httptest.NewRequest("GET", "/blogs", nil))
+	expectError(t, w, http.StatusNotFound, response.CodeNotFound)
+}
+
+func TestJWKS(t *testing.T) {
+	api := newTestAPI(t)
+
+	w := httptest.NewRecorder()
+	api.handler.ServeHTTP(w, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	var jwks struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	decode(t, w, &jwks)
+	if len(jwks.Keys) != 1 || jwks.Keys[0]["kty"] != "OKP" || jwks.Keys[0]["d"] != nil {
+		t.Errorf("unexpected key set %v", jwks.Keys)
+	}
+}
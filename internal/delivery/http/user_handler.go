@@ -1,12 +1,15 @@
 package http
 
 import (
-	"encoding/json"
+	"fmt"
 	"net/http"
 	"regexp"
 
 	"blog-api/internal/entity"
 	"blog-api/internal/usecase"
+	"blog-api/pkg/auth"
+	"blog-api/pkg/middleware"
+	"blog-api/pkg/response"
 
 	"github.com/gorilla/mux"
 	"golang.org/x/crypto/bcrypt"
@@ -16,42 +19,40 @@ type UserHandler struct {
 	UserUsecase usecase.UserUsecase
 }
 
-func NewUserHandler(r *mux.Router, userUsecase usecase.UserUsecase) {
+func NewUserHandler(r *mux.Router, userUsecase usecase.UserUsecase, authn *middleware.Auth) {
 	handler := &UserHandler{
 		UserUsecase: userUsecase,
 	}
 
 	r.HandleFunc("/register", handler.Register).Methods("POST")
 	r.HandleFunc("/login", handler.Login).Methods("POST")
+	r.HandleFunc("/token/refresh", handler.RefreshToken).Methods("POST")
+	r.Handle("/logout", authn.Required(http.HandlerFunc(handler.Logout))).Methods("POST")
+
+	// Signed in users can look up their own account; admins any account
+	r.Handle("/users/{id:[0-9]+}", authn.Required(http.HandlerFunc(handler.GetUser))).Methods("GET")
+
+	// Admins appoint authors and other admins
+	r.Handle("/users/{id:[0-9]+}/role", authn.Permission(auth.PermUserManage)(http.HandlerFunc(handler.ChangeRole))).Methods("PUT")
 }
 
 func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var user entity.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// Validate required fields
-	if user.Username == "" || user.Password == "" || user.Email == "" {
-		http.Error(w, "Username, Password, and Email are required", http.StatusBadRequest)
+	if err := decodeJSON(r, &user); err != nil {
+		writeInputError(w, r, err)
 		return
 	}
 
-	// Validate Email format
-	if !isValidEmail(user.Email) {
-		http.Error(w, "Invalid email format", http.StatusBadRequest)
-		return
-	}
-
-	// Check if the user already exists
-	existingUser, err := h.UserUsecase.GetByUsernameOrEmail(user.Username, user.Email)
-	if err != nil {
-		http.Error(w, "Failed to check existing user", http.StatusInternalServerError)
-		return
+	// Validate required fields and the email format
+	var details []response.FieldError
+	details = requireField(details, "username", user.Username)
+	details = requireField(details, "password", user.Password)
+	details = requireField(details, "email", user.Email)
+	if user.Email != "" && !isValidEmail(user.Email) {
+		details = append(details, response.FieldError{Field: "email", Message: "is not a valid email address"})
 	}
-	if existingUser != nil {
-		http.Error(w, "Username or Email already exists", http.StatusConflict)
+	if len(details) > 0 {
+		writeValidationError(w, r, details...)
 		return
 	}
 
@@ -68,7 +69,7 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 				// Optionally set an expiration time
 				MaxAge: 30,
 			})
-			http.Error(w, "Please specify your role (either 'author' or 'user').", http.StatusBadRequest)
+			writeValidationError(w, r, response.FieldError{Field: "role", Message: "please specify your role, either 'author' or 'user'"})
 			return
 		} else {
 			// If it's a subsequent attempt, set the default role to 'user'
@@ -79,14 +80,14 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	// Hash the password before saving
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
-		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
+		response.Internal(w, r, err)
 		return
 	}
 	user.Password = string(hashedPassword)
 
 	// Proceed with registration by calling the use case
-	if err := h.UserUsecase.Register(&user); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.UserUsecase.Register(r.Context(), &user); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -98,52 +99,151 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 		MaxAge: -1, // Deletes the cookie
 	})
 
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "User registered successfully with role: " + user.Role,
-	})
+	location := fmt.Sprintf("%s/users/%d", APIPrefix, user.ID)
+	response.Created(w, location, newUserResponse(&user))
+}
+
+func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	// Other users' accounts are only disclosed to admins
+	if id != principal.UserID && !principal.Can(auth.PermUserManage) {
+		writeError(w, r, usecase.ErrUserNotFound)
+		return
+	}
+
+	user, err := h.UserUsecase.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newUserResponse(user))
+}
+
+type changeRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// ChangeRole gives a user another role
+func (h *UserHandler) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+
+	var req changeRoleRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+	if details := requireField(nil, "role", req.Role); len(details) > 0 {
+		writeValidationError(w, r, details...)
+		return
+	}
+
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	user, err := h.UserUsecase.ChangeRole(r.Context(), principal, id, req.Role)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newUserResponse(user))
 }
 
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var user entity.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &user); err != nil {
+		writeInputError(w, r, err)
 		return
 	}
 
-	loggedInUser, err := h.UserUsecase.Login(user.Username, user.Password)
+	tokens, err := h.UserUsecase.Login(r.Context(), user.Username, user.Password)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		writeError(w, r, err)
 		return
 	}
 
-	userDetails, err := h.UserUsecase.GetByUsernameOrEmail(user.Username, user.Email)
+	userDetails, err := h.UserUsecase.GetByUsernameOrEmail(r.Context(), user.Username, user.Email)
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		writeError(w, r, err)
 		return
 	}
 
-	// Return both user details and the JWT toke
-	response := map[string]interface{}{
-		"token": loggedInUser,
-		"user": map[string]interface{}{
-			"user_id":  userDetails.ID,
-			"username": userDetails.Username,
-			"email":    userDetails.Email,
-			"role":     userDetails.Role,
-		},
+	// Return both user details and the tokens
+	response.JSON(w, http.StatusOK, &loginResponse{
+		tokenResponse: *newTokenResponse(tokens),
+		User:          newUserResponse(userDetails),
+	})
+}
+
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken exchanges a refresh token for a new access and refresh token.
+// The old refresh token cannot be used again.
+func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req refreshTokenRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeInputError(w, r, err)
+		return
+	}
+	if req.RefreshToken == "" {
+		writeValidationError(w, r, response.FieldError{Field: "refresh_token", Message: "is required"})
+		return
 	}
 
-	// // Generate the JWT token
-	// token, err := h.UserUsecase.GenerateJWTToken(loggedInUser.ID, loggedInUser.Role)
-	// if err != nil {
-	// 	http.Error(w, err.Error(), http.StatusUnauthorized)
-	// 	return
-	// }
+	tokens, err := h.UserUsecase.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newTokenResponse(tokens))
+}
+
+// Logout revokes the access token of the request. Sending the refresh token
+// as well ends the session, so it cannot be refreshed anymore.
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, r)
+		return
+	}
+
+	// The body is optional
+	var req refreshTokenRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			writeInputError(w, r, err)
+			return
+		}
+	}
+
+	if err := h.UserUsecase.Logout(r.Context(), principal, req.RefreshToken); err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func isValidEmail(email string) bool {
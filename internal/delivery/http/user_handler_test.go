@@ -0,0 +1,127 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"blog-api/pkg/auth"
+	"blog-api/pkg/response"
+)
+
+func TestRegister(t *testing.T) {
+	api := newTestAPI(t)
+
+	w := api.do("POST", "/register", "", map[string]string{"username": "alice", "email": "alice@example.com", "password": "password123", "role": auth.RoleAuthor})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body)
+	}
+	var user userResponse
+	decode(t, w, &user)
+	if want := APIPrefix + "/users/" + strconv.Itoa(user.ID); w.Header().Get("Location") != want {
+		t.Errorf("Location = %q, want %q", w.Header().Get("Location"), want)
+	}
+
+	w = api.do("POST", "/register", "", map[string]string{"username": "alice", "email": "other@example.com", "password": "password123", "role": auth.RoleUser})
+	expectError(t, w, http.StatusConflict, response.CodeConflict)
+
+	w = api.do("POST", "/register", "", map[string]string{"username": "bob", "email": "not an email", "role": auth.RoleUser})
+	env := expectError(t, w, http.StatusBadRequest, response.CodeValidation)
+	fields := map[string]bool{}
+	for _, detail := range env.Error.Details {
+		fields[detail.Field] = true
+	}
+	if len(fields) != 2 || !fields["password"] || !fields["email"] {
+		t.Errorf("validation details = %+v, want password and email", env.Error.Details)
+	}
+
+	w = api.do("POST", "/register", "", "{not json")
+	expectError(t, w, http.StatusBadRequest, response.CodeBadRequest)
+}
+
+func TestLogin(t *testing.T) {
+	api := newTestAPI(t)
+	api.signUp("alice", auth.RoleUser)
+
+	w := api.do("POST", "/login", "", map[string]string{"username": "alice", "password": "wrong password"})
+	expectError(t, w, http.StatusUnauthorized, response.CodeUnauthorized)
+}
+
+func TestRefreshToken(t *testing.T) {
+	api := newTestAPI(t)
+	_, tokens := api.signUp("alice", auth.RoleUser)
+
+	w := api.do("POST", "/token/refresh", "", map[string]string{})
+	env := expectError(t, w, http.StatusBadRequest, response.CodeValidation)
+	if len(env.Error.Details) != 1 || env.Error.Details[0].Field != "refresh_token" {
+		t.Errorf("validation details = %+v, want refresh_token", env.Error.Details)
+	}
+
+	w = api.do("POST", "/token/refresh", "", refreshTokenRequest{RefreshToken: tokens.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	var refreshed tokenResponse
+	decode(t, w, &refreshed)
+	if refreshed.TokenType != "Bearer" || refreshed.AccessToken == "" {
+		t.Errorf("unexpected tokens %+v", refreshed)
+	}
+
+	// The old refresh token is used up, and using it revokes the new one too
+	w = api.do("POST", "/token/refresh", "", refreshTokenRequest{RefreshToken: tokens.RefreshToken})
+	expectError(t, w, http.StatusUnauthorized, response.CodeUnauthorized)
+	w = api.do("POST", "/token/refresh", "", refreshTokenRequest{RefreshToken: refreshed.RefreshToken})
+	expectError(t, w, http.StatusUnauthorized, response.CodeUnauthorized)
+}
+
+func TestLogout(t *testing.T) {
+	api := newTestAPI(t)
+	id, tokens := api.signUp("alice", auth.RoleUser)
+	path := "/users/" + strconv.Itoa(id)
+
+	expectError(t, api.do("POST", "/logout", "", nil), http.StatusUnauthorized, response.CodeUnauthorized)
+
+	if w := api.do("GET", path, tokens.AccessToken, nil); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+
+	w := api.do("POST", "/logout", tokens.AccessToken, refreshTokenRequest{RefreshToken: tokens.RefreshToken})
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204: %s", w.Code, w.Body)
+	}
+
+	// Neither token works after logging out
+	expectError(t, api.do("GET", path, tokens.AccessToken, nil), http.StatusUnauthorized, response.CodeUnauthorized)
+	w = api.do("POST", "/token/refresh", "", refreshTokenRequest{RefreshToken: tokens.RefreshToken})
+	expectError(t, w, http.StatusUnauthorized, response.CodeUnauthorized)
+}
+
+func TestGetUser(t *testing.T) {
+	api := newTestAPI(t)
+	aliceID, alice := api.signUp("alice", auth.RoleUser)
+	bobID, _ := api.signUp("bob", auth.RoleUser)
+
+	expectError(t, api.do("GET", "/users/"+strconv.Itoa(aliceID), "", nil), http.StatusUnauthorized, response.CodeUnauthorized)
+	expectError(t, api.do("GET", "/users/"+strconv.Itoa(aliceID), "not-a-token", nil), http.StatusUnauthorized, response.CodeUnauthorized)
+
+	// Other accounts are hidden from everyone but admins
+	expectError(t, api.do("GET", "/users/"+strconv.Itoa(bobID), alice.AccessToken, nil), http.StatusNotFound, response.CodeNotFound)
+}
+
+func TestChangeRole(t *testing.T) {
+	api := newTestAPI(t)
+	aliceID, alice := api.signUp("alice", auth.RoleUser)
+	path := "/users/" + strconv.Itoa(aliceID) + "/role"
+
+	// Only admins hold the permission, and admins are appointed
+	w := api.do("PUT", path, alice.AccessToken, changeRoleRequest{Role: auth.RoleAdmin})
+	expectError(t, w, http.StatusForbidden, response.CodeForbidden)
+
+	// The token issued for the old role is rejected as soon as the role changes
+	admin := &auth.Principal{UserID: 100, Role: auth.RoleAdmin, Scopes: auth.RolePermissions(auth.RoleAdmin)}
+	if _, err := api.users.ChangeRole(context.Background(), admin, aliceID, auth.RoleAuthor); err != nil {
+		t.Fatal(err)
+	}
+	expectError(t, api.do("GET", "/users/"+strconv.Itoa(aliceID), alice.AccessToken, nil), http.StatusUnauthorized, response.CodeUnauthorized)
+}
@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"blog-api/internal/usecase"
+)
+
+// FileCleaner periodically removes stored media files nothing uses anymore
+type FileCleaner struct {
+	MediaUsecase usecase.MediaUsecase
+	Interval     time.Duration
+}
+
+func NewFileCleaner(mediaUsecase usecase.MediaUsecase, interval time.Duration) *FileCleaner {
+	return &FileCleaner{
+		MediaUsecase: mediaUsecase,
+		Interval:     interval,
+	}
+}
+
+// Run removes due files on every tick until the context is cancelled
+func (c *FileCleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	log.Printf("File cleaner started, checking every %s", c.Interval)
+	for {
+		c.cleanUp(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Println("File cleaner stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *FileCleaner) cleanUp(ctx context.Context) {
+	removed, err := c.MediaUsecase.CleanUpFiles(ctx, time.Now())
+	if err != nil {
+		log.Println("Error removing unused files:", err)
+		return
+	}
+	if removed > 0 {
+		log.Printf("Removed %d unused file(s)", removed)
+	}
+}
@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"blog-api/internal/usecase"
+)
+
+// KeyRotator periodically rotates the token signing keys and reloads them,
+// picking up keys other replicas created
+type KeyRotator struct {
+	SigningKeyUsecase usecase.SigningKeyUsecase
+	Interval          time.Duration
+}
+
+func NewKeyRotator(signingKeyUsecase usecase.SigningKeyUsecase, interval time.Duration) *KeyRotator {
+	return &KeyRotator{
+		SigningKeyUsecase: signingKeyUsecase,
+		Interval:          interval,
+	}
+}
+
+// Run rotates the keys on every tick until the context is cancelled
+func (k *KeyRotator) Run(ctx context.Context) {
+	ticker := time.NewTicker(k.Interval)
+	defer ticker.Stop()
+
+	log.Printf("Key rotator started, checking every %s", k.Interval)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Key rotator stopped")
+			return
+		case <-ticker.C:
+		}
+
+		// Keep serving with the loaded keys when this fails; the next tick retries
+		if err := k.SigningKeyUsecase.RotateKeys(ctx, time.Now()); err != nil {
+			log.Println("Error rotating signing keys:", err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"blog-api/internal/usecase"
+)
+
+// Publisher periodically promotes scheduled blogs whose publish_at has passed
+type Publisher struct {
+	BlogUsecase usecase.BlogUsecase
+	Interval    time.Duration
+}
+
+func NewPublisher(blogUsecase usecase.BlogUsecase, interval time.Duration) *Publisher {
+	return &Publisher{
+		BlogUsecase: blogUsecase,
+		Interval:    interval,
+	}
+}
+
+// Run publishes due blogs on every tick until the context is cancelled
+func (p *Publisher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	log.Printf("Scheduled publisher started, checking every %s", p.Interval)
+	for {
+		p.publishDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Println("Scheduled publisher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Publisher) publishDue(ctx context.Context) {
+	published, err := p.BlogUsecase.PublishDue(ctx, time.Now())
+	if err != nil {
+		log.Println("Error publishing scheduled blogs:", err)
+		return
+	}
+	if published > 0 {
+		log.Printf("Published %d scheduled blog(s)", published)
+	}
+}
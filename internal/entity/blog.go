@@ -1,9 +1,92 @@
 package entity
 
+import "time"
+
 type Blog struct {
-	ID        int
-	Title     string
-	Content   string
-	UserID    int
-	Thumbnail string
+	ID          int
+	Title       string
+	Slug        string
+	Content     string
+	UserID      int
+	Thumbnail   string
+	CategoryID  *int
+	Tags        []string
+	Status      string
+	CommentMode string
+	PublishAt   *time.Time
+	PublishedAt *time.Time
+	CreatedAt   time.Time
+}
+
+// Blog statuses; only published blogs are visible to readers
+const (
+	BlogStatusDraft     = "draft"
+	BlogStatusScheduled = "scheduled"
+	BlogStatusPublished = "published"
+	BlogStatusArchived  = "archived"
+)
+
+// Comment modes; they decide whether new comments on a blog are accepted
+// right away, held for the author's approval or refused
+const (
+	CommentModeOpen      = "open"
+	CommentModeModerated = "moderated"
+	CommentModeClosed    = "closed"
+)
+
+// Sort orders supported when listing blogs
+const (
+	BlogSortNewest = "newest"
+	BlogSortOldest = "oldest"
+	BlogSortTitle  = "title"
+)
+
+// BlogFilter describes which page of blogs to list and in which order
+type BlogFilter struct {
+	Page   int
+	Limit  int
+	Sort   string
+	UserID int
+	Cursor string
+	Status string
+	Tag    string
+
+	// Category is a category slug; blogs in its subcategories are included too
+	Category    string
+	CategoryIDs []int
+
+	// ViewerID is the requesting user, whose own unpublished blogs are included
+	ViewerID int
+
+	// After is the decoded Cursor, set by the usecase for keyset pagination
+	After *BlogCursor
+}
+
+// BlogCursor identifies the last blog of a page so the next one can start after it
+type BlogCursor struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// BlogPage is a single page of blogs along with the total number of matches
+type BlogPage struct {
+	Blogs      []*Blog
+	Total      int
+	HasMore    bool
+	NextCursor string
+}
+
+// BlogSearchResult is a blog matched by a full-text search
+type BlogSearchResult struct {
+	*Blog
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet"`
+}
+
+// BlogSearchPage is a single page of search results ordered by relevance
+type BlogSearchPage struct {
+	Results []*BlogSearchResult
+	Total   int
+	HasMore bool
 }
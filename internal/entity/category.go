@@ -0,0 +1,19 @@
+package entity
+
+// Category groups blogs in a tree; a category without a parent is a root
+type Category struct {
+	ID       int         `json:"id"`
+	Name     string      `json:"name"`
+	Slug     string      `json:"slug"`
+	ParentID *int        `json:"parent_id"`
+	Children []*Category `json:"children,omitempty"`
+
+	// PostCount is the number of published blogs in the category and its subcategories
+	PostCount int `json:"post_count"`
+}
+
+// Tag is a free-form label attached to blogs
+type Tag struct {
+	Name      string `json:"name"`
+	PostCount int    `json:"post_count"`
+}
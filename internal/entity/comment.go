@@ -1,8 +1,56 @@
 package entity
 
+import "time"
+
 type Comment struct {
-	ID      int    `json:"id"`
-	Content string `json:"content"`
-	UserID  int    `json:"user_id"`
-	BlogID  int    `json:"blog_id"`
+	ID        int       `json:"id"`
+	Content   string    `json:"content"`
+	UserID    int       `json:"user_id"`
+	BlogID    int       `json:"blog_id"`
+	ParentID  *int      `json:"parent_id"`
+	Depth     int       `json:"depth"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// RootID is the top-level comment of the thread, nil for top-level comments
+	RootID *int `json:"-"`
+
+	// Replies holds the nested replies when comments are returned as a tree
+	Replies []*Comment `json:"replies,omitempty"`
+}
+
+// Comment statuses; only approved comments are shown publicly
+const (
+	CommentStatusPending  = "pending"
+	CommentStatusApproved = "approved"
+	CommentStatusRejected = "rejected"
+)
+
+// CommentFilter selects a page of the top-level comments on a blog
+type CommentFilter struct {
+	BlogID int
+	Page   int
+	Limit  int
+
+	// ViewerID is the requesting user, whose own unapproved comments are included
+	ViewerID int
+
+	// Flat returns the threads as a single list in reading order instead of a tree
+	Flat bool
+}
+
+// CommentPage is a single page of comment threads along with the total number of threads
+type CommentPage struct {
+	Comments []*Comment
+	Total    int
+	HasMore  bool
+}
+
+// ModerationFilter selects a page of the comments awaiting approval on an
+// author's blogs, or on every blog when AuthorID is zero
+type ModerationFilter struct {
+	AuthorID int
+	Page     int
+	Limit    int
 }
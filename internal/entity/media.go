@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+// Media is an image in an author's media library. It can be used as a blog's
+// thumbnail or embedded in blog content by its URL.
+type Media struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	Path        string    `json:"path"`
+	ContentType string    `json:"content_type"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Size        int       `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// MediaFilter selects a page of an author's media library
+type MediaFilter struct {
+	UserID int
+	Page   int
+	Limit  int
+}
+
+// MediaPage is a single page of media along with the total number of items
+type MediaPage struct {
+	Media   []*Media
+	Total   int
+	HasMore bool
+}
@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// BlogRevision is an immutable snapshot of a blog taken every time it is saved
+type BlogRevision struct {
+	ID        int       `json:"id"`
+	BlogID    int       `json:"blog_id"`
+	Revision  int       `json:"revision"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Thumbnail string    `json:"thumbnail"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Diff operations
+const (
+	DiffEqual  = "="
+	DiffInsert = "+"
+	DiffDelete = "-"
+)
+
+// DiffLine is a single line of a line-based diff
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// RevisionDiff describes the changes between two revisions of a blog
+type RevisionDiff struct {
+	From      int        `json:"from"`
+	To        int        `json:"to"`
+	Title     []DiffLine `json:"title"`
+	Content   []DiffLine `json:"content"`
+	Thumbnail []DiffLine `json:"thumbnail"`
+}
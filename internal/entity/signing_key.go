@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// SigningKey is a stored access token signing key. A key signs from
+// NotBefore until a newer key takes over; NotAfter is set once a newer key
+// is scheduled and marks when the tokens it signed have all expired.
+type SigningKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey []byte
+	NotBefore  time.Time
+	NotAfter   *time.Time
+	CreatedAt  time.Time
+}
@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// RefreshToken is a stored refresh token. Only a hash of the token is kept.
+// Every refresh replaces the token with a new one of the same family, so a
+// family traces one login session.
+type RefreshToken struct {
+	ID        int
+	UserID    int
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// TokenPair is issued on login and on every refresh
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	// ExpiresIn is how long the access token is valid
+	ExpiresIn time.Duration
+}
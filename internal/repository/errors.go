@@ -0,0 +1,11 @@
+package repository
+
+import "errors"
+
+var (
+	// ErrNotFound is returned by repositories when a looked up record does not exist
+	ErrNotFound = errors.New("record not found")
+
+	// ErrDuplicateSlug is returned when saving a blog whose slug another blog took first
+	ErrDuplicateSlug = errors.New("slug is already taken")
+)
@@ -0,0 +1,500 @@
+package memory
+
+import (
+	"blog-api/internal/entity"
+	"blog-api/internal/repository"
+	"context"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type BlogRepository struct {
+	db *DB
+}
+
+func NewBlogRepository(db *DB) *BlogRepository {
+	return &BlogRepository{db: db}
+}
+
+func (r *BlogRepository) Create(ctx context.Context, blog *entity.Blog) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.slugUsed(blog.Slug, 0) {
+		return repository.ErrDuplicateSlug
+	}
+
+	blog.ID = r.db.nextID("blogs")
+	blog.CreatedAt = r.db.now()
+	r.db.blogs[blog.ID] = copyBlog(blog)
+
+	// The original version of the blog is its first revision
+	r.addRevision(blog)
+	return nil
+}
+
+func (r *BlogRepository) GetAll(ctx context.Context, filter *entity.BlogFilter) (*entity.BlogPage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	blogs := r.filter(filter)
+	page := &entity.BlogPage{Total: len(blogs)}
+
+	less := blogOrder(filter.Sort)
+	sort.Slice(blogs, func(i, j int) bool { return less(blogs[i], blogs[j]) })
+
+	// Continue after the cursor when one is given (keyset pagination)
+	offset := (filter.Page - 1) * filter.Limit
+	if c := filter.After; c != nil {
+		after := &entity.Blog{ID: c.ID, Title: c.Title, CreatedAt: c.CreatedAt}
+		offset = sort.Search(len(blogs), func(i int) bool { return less(after, blogs[i]) })
+	}
+
+	blogs, page.HasMore = paginate(blogs, offset, filter.Limit)
+	page.Blogs = copyBlogs(blogs)
+	return page, nil
+}
+
+// Search ranks blogs by how often the query's words occur in their title and
+// content. It approximates MySQL's natural language full-text search.
+func (r *BlogRepository) Search(ctx context.Context, query string, filter *entity.BlogFilter) (*entity.BlogSearchPage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	terms := strings.FieldsFunc(strings.ToLower(query), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+
+	results := []*entity.BlogSearchResult{}
+	for _, blog := range r.filter(filter) {
+		text := strings.ToLower(blog.Title + " " + blog.Content)
+		score := 0
+		for _, term := range terms {
+			score += strings.Count(text, term)
+		}
+		if score > 0 {
+			results = append(results, &entity.BlogSearchResult{Blog: blog, Score: float64(score)})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID > results[j].ID
+	})
+
+	page := &entity.BlogSearchPage{Total: len(results)}
+	results, page.HasMore = paginate(results, (filter.Page-1)*filter.Limit, filter.Limit)
+	for _, result := range results {
+		result.Blog = copyBlog(result.Blog)
+	}
+	page.Results = results
+	return page, nil
+}
+
+func (r *BlogRepository) GetByID(ctx context.Context, id int) (*entity.Blog, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	blog, ok := r.db.blogs[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return copyBlog(blog), nil
+}
+
+func (r *BlogRepository) GetBySlug(ctx context.Context, slug string) (*entity.Blog, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, blog := range r.db.blogs {
+		if blog.Slug == slug {
+			return copyBlog(blog), nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *BlogRepository) GetRedirectSlug(ctx context.Context, oldSlug string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	if blog, ok := r.db.blogs[r.db.redirects[oldSlug]]; ok {
+		return blog.Slug, nil
+	}
+	return "", nil
+}
+
+// SlugTaken reports whether a slug is used by, or redirects to, a blog other than blogID
+func (r *BlogRepository) SlugTaken(ctx context.Context, slug string, blogID int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	if id, ok := r.db.redirects[slug]; ok && id != blogID {
+		return true, nil
+	}
+	return r.slugUsed(slug, blogID), nil
+}
+
+// GetWithoutSlug returns the blogs with an empty slug, which stands for the
+// NULL slug of blogs written before slugs existed
+func (r *BlogRepository) GetWithoutSlug(ctx context.Context) ([]*entity.Blog, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	blogs := []*entity.Blog{}
+	for _, blog := range r.db.blogs {
+		if blog.Slug == "" {
+			blogs = append(blogs, &entity.Blog{ID: blog.ID, Title: blog.Title})
+		}
+	}
+	sort.Slice(blogs, func(i, j int) bool { return blogs[i].ID < blogs[j].ID })
+	return blogs, nil
+}
+
+func (r *BlogRepository) SetSlug(ctx context.Context, blog *entity.Blog) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored, ok := r.db.blogs[blog.ID]
+	if !ok || stored.Slug != "" {
+		return nil
+	}
+	if r.slugUsed(blog.Slug, blog.ID) {
+		return repository.ErrDuplicateSlug
+	}
+	stored.Slug = blog.Slug
+	return nil
+}
+
+// slugUsed reports whether a blog other than blogID has the slug, which the
+// database enforces with a unique key; the caller holds the lock. Like the
+// NULLs they stand for, any number of blogs may lack a slug.
+func (r *BlogRepository) slugUsed(slug string, blogID int) bool {
+	if slug == "" {
+		return false
+	}
+	for _, blog := range r.db.blogs {
+		if blog.Slug == slug && blog.ID != blogID {
+			return true
+		}
+	}
+	return false
+}
+
+// Update saves the blog and records the new version as a revision
+func (r *BlogRepository) Update(ctx context.Context, blog *entity.Blog) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored, ok := r.db.blogs[blog.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if r.slugUsed(blog.Slug, blog.ID) {
+		return repository.ErrDuplicateSlug
+	}
+
+	// Keep the old slug as a redirect when the slug changes
+	if stored.Slug != "" && stored.Slug != blog.Slug {
+		r.db.redirects[stored.Slug] = blog.ID
+		if r.db.redirects[blog.Slug] == blog.ID {
+			delete(r.db.redirects, blog.Slug)
+		}
+	}
+
+	stored.Title = blog.Title
+	stored.Slug = blog.Slug
+	stored.Content = blog.Content
+	stored.Thumbnail = blog.Thumbnail
+	stored.CategoryID = copyInt(blog.CategoryID)
+	stored.Tags = sortedTags(blog.Tags)
+
+	r.addRevision(blog)
+	return nil
+}
+
+func (r *BlogRepository) UpdateStatus(ctx context.Context, blog *entity.Blog) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if stored, ok := r.db.blogs[blog.ID]; ok {
+		stored.Status = blog.Status
+		stored.PublishAt = copyTime(blog.PublishAt)
+		stored.PublishedAt = copyTime(blog.PublishedAt)
+	}
+	return nil
+}
+
+func (r *BlogRepository) UpdateCommentMode(ctx context.Context, blog *entity.Blog) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if stored, ok := r.db.blogs[blog.ID]; ok {
+		stored.CommentMode = blog.CommentMode
+	}
+	return nil
+}
+
+// PublishDue publishes the scheduled blogs that are due
+func (r *BlogRepository) PublishDue(ctx context.Context, now time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var published int64
+	for _, blog := range r.db.blogs {
+		if blog.Status == entity.BlogStatusScheduled && blog.PublishAt != nil && !blog.PublishAt.After(now) {
+			blog.Status = entity.BlogStatusPublished
+			blog.PublishedAt = blog.PublishAt
+			blog.PublishAt = nil
+			published++
+		}
+	}
+	return published, nil
+}
+
+// Delete removes a blog along with its revisions, redirects and comments
+func (r *BlogRepository) Delete(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	delete(r.db.blogs, id)
+	delete(r.db.revisions, id)
+	for slug, blogID := range r.db.redirects {
+		if blogID == id {
+			delete(r.db.redirects, slug)
+		}
+	}
+	for commentID, comment := range r.db.comments {
+		if comment.BlogID == id {
+			delete(r.db.comments, commentID)
+		}
+	}
+	return nil
+}
+
+func (r *BlogRepository) GetRevisions(ctx context.Context, blogID int) ([]*entity.BlogRevision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	stored := r.db.revisions[blogID]
+	revisions := make([]*entity.BlogRevision, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		rev := *stored[i]
+		revisions = append(revisions, &rev)
+	}
+	return revisions, nil
+}
+
+func (r *BlogRepository) GetRevision(ctx context.Context, blogID, revision int) (*entity.BlogRevision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, stored := range r.db.revisions[blogID] {
+		if stored.Revision == revision {
+			rev := *stored
+			return &rev, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+// GetTags returns every tag used by a published blog, most used first
+func (r *BlogRepository) GetTags(ctx context.Context) ([]*entity.Tag, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, blog := range r.db.blogs {
+		if blog.Status != entity.BlogStatusPublished {
+			continue
+		}
+		for _, tag := range blog.Tags {
+			counts[tag]++
+		}
+	}
+
+	tags := make([]*entity.Tag, 0, len(counts))
+	for name, count := range counts {
+		tags = append(tags, &entity.Tag{Name: name, PostCount: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].PostCount != tags[j].PostCount {
+			return tags[i].PostCount > tags[j].PostCount
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+// addRevision stores the current state of the blog as its next revision; the
+// caller holds the lock
+func (r *BlogRepository) addRevision(blog *entity.Blog) {
+	revisions := r.db.revisions[blog.ID]
+	r.db.revisions[blog.ID] = append(revisions, &entity.BlogRevision{
+		ID:        r.db.nextID("blog_revisions"),
+		BlogID:    blog.ID,
+		Revision:  len(revisions) + 1,
+		Title:     blog.Title,
+		Content:   blog.Content,
+		Thumbnail: blog.Thumbnail,
+		CreatedAt: r.db.now(),
+	})
+}
+
+// filter returns the stored blogs matching a listing filter. Only published
+// blogs are visible, plus any blog owned by the viewer.
+func (r *BlogRepository) filter(filter *entity.BlogFilter) []*entity.Blog {
+	var categories map[int]bool
+	if filter.CategoryIDs != nil {
+		categories = make(map[int]bool, len(filter.CategoryIDs))
+		for _, id := range filter.CategoryIDs {
+			categories[id] = true
+		}
+	}
+
+	blogs := []*entity.Blog{}
+	for _, blog := range r.db.blogs {
+		switch {
+		case blog.Status != entity.BlogStatusPublished && blog.UserID != filter.ViewerID:
+		case filter.Status != "" && blog.Status != filter.Status:
+		case filter.UserID != 0 && blog.UserID != filter.UserID:
+		case filter.Tag != "" && !hasTag(blog, filter.Tag):
+		case categories != nil && (blog.CategoryID == nil || !categories[*blog.CategoryID]):
+		default:
+			blogs = append(blogs, blog)
+		}
+	}
+	return blogs
+}
+
+// blogOrder returns the comparison matching a listing's sort order
+func blogOrder(sortOrder string) func(a, b *entity.Blog) bool {
+	switch sortOrder {
+	case entity.BlogSortOldest:
+		return func(a, b *entity.Blog) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return a.ID < b.ID
+		}
+	case entity.BlogSortTitle:
+		return func(a, b *entity.Blog) bool {
+			if a.Title != b.Title {
+				return a.Title < b.Title
+			}
+			return a.ID < b.ID
+		}
+	default:
+		return func(a, b *entity.Blog) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return a.ID > b.ID
+		}
+	}
+}
+
+func hasTag(blog *entity.Blog, tag string) bool {
+	for _, t := range blog.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedTags copies tags in name order, the order they are loaded in
+func sortedTags(tags []string) []string {
+	sorted := append([]string{}, tags...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// copyBlog returns a copy that shares no memory with the stored blog
+func copyBlog(blog *entity.Blog) *entity.Blog {
+	c := *blog
+	c.CategoryID = copyInt(blog.CategoryID)
+	c.PublishAt = copyTime(blog.PublishAt)
+	c.PublishedAt = copyTime(blog.PublishedAt)
+	c.Tags = sortedTags(blog.Tags)
+	return &c
+}
+
+func copyBlogs(blogs []*entity.Blog) []*entity.Blog {
+	copies := make([]*entity.Blog, len(blogs))
+	for i, blog := range blogs {
+		copies[i] = copyBlog(blog)
+	}
+	return copies
+}
@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"blog-api/internal/entity"
+	"blog-api/internal/repository"
+	"context"
+	"sort"
+)
+
+type CategoryRepository struct {
+	db *DB
+}
+
+func NewCategoryRepository(db *DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, category *entity.Category) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	category.ID = r.db.nextID("categories")
+	r.db.categories[category.ID] = copyCategory(category)
+	return nil
+}
+
+// GetAll returns every category along with the number of published blogs
+// filed directly under it
+func (r *CategoryRepository) GetAll(ctx context.Context) ([]*entity.Category, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	counts := make(map[int]int)
+	for _, blog := range r.db.blogs {
+		if blog.CategoryID != nil && blog.Status == entity.BlogStatusPublished {
+			counts[*blog.CategoryID]++
+		}
+	}
+
+	categories := make([]*entity.Category, 0, len(r.db.categories))
+	for _, stored := range r.db.categories {
+		category := copyCategory(stored)
+		category.PostCount = counts[category.ID]
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Name != categories[j].Name {
+			return categories[i].Name < categories[j].Name
+		}
+		return categories[i].ID < categories[j].ID
+	})
+	return categories, nil
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, id int) (*entity.Category, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	category, ok := r.db.categories[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return copyCategory(category), nil
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, category *entity.Category) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.categories[category.ID]; ok {
+		r.db.categories[category.ID] = copyCategory(category)
+	}
+	return nil
+}
+
+// Delete removes a category; its blogs are left uncategorized
+func (r *CategoryRepository) Delete(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	delete(r.db.categories, id)
+	for _, blog := range r.db.blogs {
+		if blog.CategoryID != nil && *blog.CategoryID == id {
+			blog.CategoryID = nil
+		}
+	}
+	return nil
+}
+
+// copyCategory returns a copy of the stored columns of a category
+func copyCategory(category *entity.Category) *entity.Category {
+	return &entity.Category{
+		ID:       category.ID,
+		Name:     category.Name,
+		Slug:     category.Slug,
+		ParentID: copyInt(category.ParentID),
+	}
+}
@@ -0,0 +1,199 @@
+package memory
+
+import (
+	"blog-api/internal/entity"
+	"blog-api/internal/repository"
+	"context"
+	"sort"
+)
+
+type CommentRepository struct {
+	db *DB
+}
+
+func NewCommentRepository(db *DB) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+func (r *CommentRepository) Create(ctx context.Context, comment *entity.Comment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	comment.ID = r.db.nextID("comments")
+	comment.CreatedAt = r.db.now()
+	comment.UpdatedAt = comment.CreatedAt
+	comment.Replies = nil
+	r.db.comments[comment.ID] = copyComment(comment)
+	return nil
+}
+
+// GetByBlog returns a page of the top-level comments on a blog, oldest first.
+// Only approved comments are included, plus the viewer's own.
+func (r *CommentRepository) GetByBlog(ctx context.Context, filter *entity.CommentFilter) (*entity.CommentPage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	comments := r.filter(func(c *entity.Comment) bool {
+		return c.BlogID == filter.BlogID && c.ParentID == nil && visibleComment(c, filter.ViewerID)
+	})
+
+	page := &entity.CommentPage{Total: len(comments)}
+	comments, page.HasMore = paginate(comments, (filter.Page-1)*filter.Limit, filter.Limit)
+	page.Comments = copyComments(comments)
+	return page, nil
+}
+
+// GetReplies returns every reply in the threads started by the given
+// top-level comments, oldest first. Only approved replies are included,
+// plus the viewer's own.
+func (r *CommentRepository) GetReplies(ctx context.Context, rootIDs []int, viewerID int) ([]*entity.Comment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	roots := make(map[int]bool, len(rootIDs))
+	for _, id := range rootIDs {
+		roots[id] = true
+	}
+	replies := r.filter(func(c *entity.Comment) bool {
+		return c.RootID != nil && roots[*c.RootID] && visibleComment(c, viewerID)
+	})
+	return copyComments(replies), nil
+}
+
+// GetPending returns a page of the comments awaiting approval, oldest first
+func (r *CommentRepository) GetPending(ctx context.Context, filter *entity.ModerationFilter) (*entity.CommentPage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	comments := r.filter(func(c *entity.Comment) bool {
+		blog, ok := r.db.blogs[c.BlogID]
+		return ok && (filter.AuthorID == 0 || blog.UserID == filter.AuthorID) && c.Status == entity.CommentStatusPending
+	})
+
+	page := &entity.CommentPage{Total: len(comments)}
+	comments, page.HasMore = paginate(comments, (filter.Page-1)*filter.Limit, filter.Limit)
+	page.Comments = copyComments(comments)
+	return page, nil
+}
+
+func (r *CommentRepository) GetByID(ctx context.Context, id int) (*entity.Comment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	comment, ok := r.db.comments[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return copyComment(comment), nil
+}
+
+func (r *CommentRepository) Update(ctx context.Context, comment *entity.Comment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if stored, ok := r.db.comments[comment.ID]; ok {
+		stored.Content = comment.Content
+		stored.Status = comment.Status
+		stored.UpdatedAt = r.db.now()
+	}
+	return nil
+}
+
+func (r *CommentRepository) UpdateStatus(ctx context.Context, comment *entity.Comment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if stored, ok := r.db.comments[comment.ID]; ok {
+		stored.Status = comment.Status
+		stored.UpdatedAt = r.db.now()
+	}
+	return nil
+}
+
+// Delete removes a comment along with the replies beneath it
+func (r *CommentRepository) Delete(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.delete(id)
+	return nil
+}
+
+func (r *CommentRepository) delete(id int) {
+	delete(r.db.comments, id)
+	for childID, comment := range r.db.comments {
+		if comment.ParentID != nil && *comment.ParentID == id {
+			r.delete(childID)
+		}
+	}
+}
+
+// filter returns the stored comments matching keep, oldest first
+func (r *CommentRepository) filter(keep func(*entity.Comment) bool) []*entity.Comment {
+	comments := []*entity.Comment{}
+	for _, comment := range r.db.comments {
+		if keep(comment) {
+			comments = append(comments, comment)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+		}
+		return comments[i].ID < comments[j].ID
+	})
+	return comments
+}
+
+func visibleComment(comment *entity.Comment, viewerID int) bool {
+	return comment.Status == entity.CommentStatusApproved || comment.UserID == viewerID
+}
+
+// copyComment returns a copy of the stored columns of a comment
+func copyComment(comment *entity.Comment) *entity.Comment {
+	c := *comment
+	c.ParentID = copyInt(comment.ParentID)
+	c.RootID = copyInt(comment.RootID)
+	c.Replies = nil
+	return &c
+}
+
+func copyComments(comments []*entity.Comment) []*entity.Comment {
+	copies := make([]*entity.Comment, len(comments))
+	for i, comment := range comments {
+		copies[i] = copyComment(comment)
+	}
+	return copies
+}
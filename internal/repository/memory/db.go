@@ -0,0 +1,93 @@
+// Package memory implements the repositories in memory. It needs no
+// database, which makes it suited to tests and local experiments; data is
+// lost when the process exits. Calls fail with ctx.Err() once their context
+// is done.
+package memory
+
+import (
+	"blog-api/internal/entity"
+	"sync"
+	"time"
+)
+
+// DB holds the records of every repository. Repositories created from the
+// same DB see each other's data, like tables of one database, and are safe
+// for concurrent use.
+type DB struct {
+	mu sync.RWMutex
+
+	users      map[int]*entity.User
+	blogs      map[int]*entity.Blog
+	redirects  map[string]int
+	revisions  map[int][]*entity.BlogRevision
+	categories map[int]*entity.Category
+	comments   map[int]*entity.Comment
+	media      map[int]*entity.Media
+	cleanups   map[string]time.Time
+
+	refreshTokens map[int]*entity.RefreshToken
+	revokedTokens map[string]time.Time
+	signingKeys   map[string]*entity.SigningKey
+
+	// signingKeyLock is held while the signing keys are rotated
+	signingKeyLock sync.Mutex
+
+	lastID map[string]int
+
+	// now returns the time records are created or updated at
+	now func() time.Time
+}
+
+func NewDB() *DB {
+	return &DB{
+		users:      make(map[int]*entity.User),
+		blogs:      make(map[int]*entity.Blog),
+		redirects:  make(map[string]int),
+		revisions:  make(map[int][]*entity.BlogRevision),
+		categories: make(map[int]*entity.Category),
+		comments:   make(map[int]*entity.Comment),
+		media:      make(map[int]*entity.Media),
+		cleanups:   make(map[string]time.Time),
+
+		refreshTokens: make(map[int]*entity.RefreshToken),
+		revokedTokens: make(map[string]time.Time),
+		signingKeys:   make(map[string]*entity.SigningKey),
+
+		lastID: make(map[string]int),
+		now:    time.Now,
+	}
+}
+
+// nextID returns the next auto increment ID of a table; the caller holds the lock
+func (db *DB) nextID(table string) int {
+	db.lastID[table]++
+	return db.lastID[table]
+}
+
+// paginate returns the items of a page along with whether more items follow
+func paginate[T any](items []T, offset, limit int) ([]T, bool) {
+	if offset > len(items) {
+		offset = len(items)
+	}
+	items = items[offset:]
+	if len(items) > limit {
+		return items[:limit], true
+	}
+	return items, false
+}
+
+func copyInt(p *int) *int {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := *t
+	return &v
+}
@@ -0,0 +1,222 @@
+package memory
+
+import (
+	"blog-api/internal/entity"
+	"blog-api/internal/repository"
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+type MediaRepository struct {
+	db *DB
+}
+
+func NewMediaRepository(db *DB) *MediaRepository {
+	return &MediaRepository{db: db}
+}
+
+// Create adds an image to a user's library. Uploading the same image twice
+// returns the existing item instead of adding a duplicate.
+func (r *MediaRepository) Create(ctx context.Context, media *entity.Media) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, stored := range r.db.media {
+		if stored.UserID == media.UserID && stored.Path == media.Path {
+			*media = *stored
+			return nil
+		}
+	}
+
+	media.ID = r.db.nextID("media")
+	media.CreatedAt = r.db.now()
+	stored := *media
+	r.db.media[media.ID] = &stored
+	return nil
+}
+
+// GetByUser returns a page of a user's library, newest first
+func (r *MediaRepository) GetByUser(ctx context.Context, filter *entity.MediaFilter) (*entity.MediaPage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	items := []*entity.Media{}
+	for _, stored := range r.db.media {
+		if stored.UserID == filter.UserID {
+			media := *stored
+			items = append(items, &media)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
+			return items[i].CreatedAt.After(items[j].CreatedAt)
+		}
+		return items[i].ID > items[j].ID
+	})
+
+	page := &entity.MediaPage{Total: len(items)}
+	page.Media, page.HasMore = paginate(items, (filter.Page-1)*filter.Limit, filter.Limit)
+	return page, nil
+}
+
+func (r *MediaRepository) GetByID(ctx context.Context, id int) (*entity.Media, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	stored, ok := r.db.media[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	media := *stored
+	return &media, nil
+}
+
+// UsedByUser reports whether any of a user's blogs shows the file as its
+// thumbnail or embeds it in its content
+func (r *MediaRepository) UsedByUser(ctx context.Context, path string, userID int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, blog := range r.db.blogs {
+		if blog.UserID == userID && usesFile(blog, path) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// FileInUse reports whether a stored file is still needed: shown as a
+// thumbnail, embedded in content, kept by a revision that can be restored
+// or kept in someone's media library
+func (r *MediaRepository) FileInUse(ctx context.Context, path string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return r.fileInUse(path), nil
+}
+
+// fileInUse reports whether anything still needs a stored file; the caller
+// holds the lock
+func (r *MediaRepository) fileInUse(path string) bool {
+	for _, blog := range r.db.blogs {
+		if usesFile(blog, path) {
+			return true
+		}
+	}
+	for _, revisions := range r.db.revisions {
+		for _, rev := range revisions {
+			if rev.Thumbnail == path {
+				return true
+			}
+		}
+	}
+	for _, media := range r.db.media {
+		if media.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MediaRepository) Delete(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	delete(r.db.media, id)
+	return nil
+}
+
+// ScheduleCleanup has a stored file checked for removal at dueAt. A file
+// that is already scheduled keeps the later of both times.
+func (r *MediaRepository) ScheduleCleanup(ctx context.Context, path string, dueAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if scheduled, ok := r.db.cleanups[path]; !ok || dueAt.After(scheduled) {
+		r.db.cleanups[path] = dueAt
+	}
+	return nil
+}
+
+// DueCleanups returns the files due for removal, longest overdue first
+func (r *MediaRepository) DueCleanups(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var paths []string
+	for path, dueAt := range r.db.cleanups {
+		if !dueAt.After(now) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool { return r.db.cleanups[paths[i]].Before(r.db.cleanups[paths[j]]) })
+	if len(paths) > limit {
+		paths = paths[:limit]
+	}
+	return paths, nil
+}
+
+// CleanUp calls remove for a file that is due and no longer in use, and
+// reports whether it did. The lock is held meanwhile, so uploads of the same
+// file wait until it is gone. Files that are still used are dropped from the
+// schedule; a failed removal is retried on the next run.
+func (r *MediaRepository) CleanUp(ctx context.Context, path string, now time.Time, remove func(ctx context.Context) error) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	dueAt, ok := r.db.cleanups[path]
+	if !ok || dueAt.After(now) {
+		return false, nil
+	}
+
+	inUse := r.fileInUse(path)
+	if !inUse {
+		if err := remove(ctx); err != nil {
+			return false, err
+		}
+	}
+	delete(r.db.cleanups, path)
+	return !inUse, nil
+}
+
+func usesFile(blog *entity.Blog, path string) bool {
+	return blog.Thumbnail == path || strings.Contains(blog.Content, path)
+}
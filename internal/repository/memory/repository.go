@@ -0,0 +1,14 @@
+package memory
+
+import "blog-api/internal/usecase"
+
+// The in-memory repositories are drop-in replacements for the MySQL ones
+var (
+	_ usecase.BlogRepository       = (*BlogRepository)(nil)
+	_ usecase.CategoryRepository   = (*CategoryRepository)(nil)
+	_ usecase.CommentRepository    = (*CommentRepository)(nil)
+	_ usecase.MediaRepository      = (*MediaRepository)(nil)
+	_ usecase.UserRepository       = (*UserRepository)(nil)
+	_ usecase.TokenRepository      = (*TokenRepository)(nil)
+	_ usecase.SigningKeyRepository = (*SigningKeyRepository)(nil)
+)
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"blog-api/internal/entity"
+	"context"
+	"sort"
+	"time"
+)
+
+type SigningKeyRepository struct {
+	db *DB
+}
+
+func NewSigningKeyRepository(db *DB) *SigningKeyRepository {
+	return &SigningKeyRepository{db: db}
+}
+
+// LockSigningKeys makes rotations through repositories of the same DB take turns
+func (r *SigningKeyRepository) LockSigningKeys(ctx context.Context) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.signingKeyLock.Lock()
+	return r.db.signingKeyLock.Unlock, nil
+}
+
+func (r *SigningKeyRepository) ListSigningKeys(ctx context.Context) ([]*entity.SigningKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	keys := make([]*entity.SigningKey, 0, len(r.db.signingKeys))
+	for _, key := range r.db.signingKeys {
+		keys = append(keys, copySigningKey(key))
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].NotBefore.Before(keys[j].NotBefore) })
+	return keys, nil
+}
+
+// RotateSigningKey stores a new key and retires the current ones
+func (r *SigningKeyRepository) RotateSigningKey(ctx context.Context, key *entity.SigningKey, retireAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, current := range r.db.signingKeys {
+		if current.NotAfter == nil {
+			notAfter := retireAt
+			current.NotAfter = &notAfter
+		}
+	}
+	key.CreatedAt = r.db.now()
+	r.db.signingKeys[key.ID] = copySigningKey(key)
+	return nil
+}
+
+func (r *SigningKeyRepository) DeleteExpiredSigningKeys(ctx context.Context, now time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for id, key := range r.db.signingKeys {
+		if key.NotAfter != nil && !now.Before(*key.NotAfter) {
+			delete(r.db.signingKeys, id)
+		}
+	}
+	return nil
+}
+
+func copySigningKey(key *entity.SigningKey) *entity.SigningKey {
+	c := *key
+	c.PrivateKey = append([]byte(nil), key.PrivateKey...)
+	c.NotAfter = copyTime(key.NotAfter)
+	return &c
+}
@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"blog-api/internal/entity"
+	"blog-api/internal/repository"
+	"context"
+	"time"
+)
+
+type TokenRepository struct {
+	db *DB
+}
+
+func NewTokenRepository(db *DB) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+func (r *TokenRepository) CreateRefreshToken(ctx context.Context, token *entity.RefreshToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	token.ID = r.db.nextID("refresh_tokens")
+	token.CreatedAt = r.db.now()
+	r.db.refreshTokens[token.ID] = copyRefreshToken(token)
+	return nil
+}
+
+func (r *TokenRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, token := range r.db.refreshTokens {
+		if token.TokenHash == tokenHash {
+			return copyRefreshToken(token), nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+// UseRefreshToken marks a refresh token as used and reports whether it was
+// still unused
+func (r *TokenRepository) UseRefreshToken(ctx context.Context, id int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	token, ok := r.db.refreshTokens[id]
+	if !ok || token.UsedAt != nil || token.RevokedAt != nil {
+		return false, nil
+	}
+	now := r.db.now()
+	token.UsedAt = &now
+	return true, nil
+}
+
+// RevokeFamily revokes every refresh token of a login session
+func (r *TokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := r.db.now()
+	for _, token := range r.db.refreshTokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			revokedAt := now
+			token.RevokedAt = &revokedAt
+		}
+	}
+	return nil
+}
+
+// RevokeUserFamilies revokes the refresh tokens of every session of a user
+func (r *TokenRepository) RevokeUserFamilies(ctx context.Context, userID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := r.db.now()
+	for _, token := range r.db.refreshTokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			revokedAt := now
+			token.RevokedAt = &revokedAt
+		}
+	}
+	return nil
+}
+
+// RevokeAccessToken adds an access token to the denylist until it expires
+func (r *TokenRepository) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := r.db.now()
+	for id, expiry := range r.db.revokedTokens {
+		if expiry.Before(now) {
+			delete(r.db.revokedTokens, id)
+		}
+	}
+	r.db.revokedTokens[tokenID] = expiresAt
+	return nil
+}
+
+func (r *TokenRepository) IsAccessTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	_, revoked := r.db.revokedTokens[tokenID]
+	return revoked, nil
+}
+
+func copyRefreshToken(token *entity.RefreshToken) *entity.RefreshToken {
+	c := *token
+	c.UsedAt = copyTime(token.UsedAt)
+	c.RevokedAt = copyTime(token.RevokedAt)
+	return &c
+}
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"blog-api/internal/entity"
+	"blog-api/internal/repository"
+	"context"
+	"sort"
+)
+
+type UserRepository struct {
+	db *DB
+}
+
+func NewUserRepository(db *DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+func (r *UserRepository) Create(ctx context.Context, user *entity.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user.ID = r.db.nextID("users")
+	stored := *user
+	r.db.users[user.ID] = &stored
+	return nil
+}
+
+// GetByID returns a user without the password hash
+func (r *UserRepository) GetByID(ctx context.Context, id int) (*entity.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return r.find(func(u *entity.User) bool { return u.ID == id }, false)
+}
+
+func (r *UserRepository) GetByUsernameOrEmail(ctx context.Context, username, email string) (*entity.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return r.find(func(u *entity.User) bool { return u.Username == username || u.Email == email }, false)
+}
+
+// GetByUsername returns a user along with the password hash, for logging in
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return r.find(func(u *entity.User) bool { return u.Username == username }, true)
+}
+
+func (r *UserRepository) UpdateRole(ctx context.Context, id int, role string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user, ok := r.db.users[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	user.Role = role
+	return nil
+}
+
+// find returns a copy of the first user, by ID, that matches
+func (r *UserRepository) find(match func(*entity.User) bool, withPassword bool) (*entity.User, error) {
+	ids := make([]int, 0, len(r.db.users))
+	for id := range r.db.users {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		if stored := r.db.users[id]; match(stored) {
+			user := *stored
+			if !withPassword {
+				user.Password = ""
+			}
+			return &user, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
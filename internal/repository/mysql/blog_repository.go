@@ -2,65 +2,340 @@ package mysql
 
 import (
 	"blog-api/internal/entity"
+	"context"
 	"database/sql"
+	"strings"
+	"time"
 )
 
+// blogColumns lists the columns scanned by blogFields, in the same order; a
+// blog written before slugs existed has none until BackfillSlugs runs
+const blogColumns = "id, title, COALESCE(slug, ''), content, user_id, thumbnail, category_id, status, comment_mode, publish_at, published_at, created_at"
+
 type BlogRepository struct {
-	DB *sql.DB
+	DB      *sql.DB
+	Timeout time.Duration
 }
 
-func NewBlogRepository(db *sql.DB) *BlogRepository {
-	return &BlogRepository{DB: db}
+func NewBlogRepository(db *sql.DB, timeout time.Duration) *BlogRepository {
+	return &BlogRepository{DB: db, Timeout: timeout}
 }
 
-func (r *BlogRepository) Create(blog *entity.Blog) error {
-	_, err := r.DB.Exec("INSERT INTO blogs (title, content, user_id, thumbnail) VALUES (?, ?, ?, ?)",
-		blog.Title, blog.Content, blog.UserID, blog.Thumbnail)
-	return err
+func (r *BlogRepository) Create(ctx context.Context, blog *entity.Blog) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO blogs (title, slug, content, user_id, thumbnail, category_id, status, comment_mode, publish_at, published_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		blog.Title, blog.Slug, blog.Content, blog.UserID, blog.Thumbnail, blog.CategoryID, blog.Status, blog.CommentMode, blog.PublishAt, blog.PublishedAt)
+	if err != nil {
+		return duplicateSlug(err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	blog.ID = int(id)
+
+	// Read back the creation time the database fills in
+	if err := tx.QueryRowContext(ctx, "SELECT created_at FROM blogs WHERE id = ?", blog.ID).Scan(&blog.CreatedAt); err != nil {
+		return err
+	}
+
+	if err := replaceTags(ctx, tx, blog); err != nil {
+		return err
+	}
+
+	// The original version of the blog is its first revision
+	if err := insertRevision(ctx, tx, blog); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (r *BlogRepository) GetAll() ([]*entity.Blog, error) {
-	rows, err := r.DB.Query("SELECT id, title, content, user_id, thumbnail FROM blogs")
+func (r *BlogRepository) GetAll(ctx context.Context, filter *entity.BlogFilter) (*entity.BlogPage, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	where, args := filterConditions(filter)
+
+	// Count every matching blog before the cursor narrows the result set
+	countQuery := "SELECT COUNT(*) FROM blogs" + whereClause(where)
+	var total int
+	if err := r.DB.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	// Continue after the cursor when one is given (keyset pagination)
+	if c := filter.After; c != nil {
+		switch filter.Sort {
+		case entity.BlogSortOldest:
+			where = append(where, "(created_at > ? OR (created_at = ? AND id > ?))")
+			args = append(args, c.CreatedAt, c.CreatedAt, c.ID)
+		case entity.BlogSortTitle:
+			where = append(where, "(title > ? OR (title = ? AND id > ?))")
+			args = append(args, c.Title, c.Title, c.ID)
+		default:
+			where = append(where, "(created_at < ? OR (created_at = ? AND id < ?))")
+			args = append(args, c.CreatedAt, c.CreatedAt, c.ID)
+		}
+	}
+
+	query := "SELECT " + blogColumns + " FROM blogs" + whereClause(where)
+	switch filter.Sort {
+	case entity.BlogSortOldest:
+		query += " ORDER BY created_at ASC, id ASC"
+	case entity.BlogSortTitle:
+		query += " ORDER BY title ASC, id ASC"
+	default:
+		query += " ORDER BY created_at DESC, id DESC"
+	}
+
+	// Fetch one extra row to find out whether another page follows
+	query += " LIMIT ?"
+	args = append(args, filter.Limit+1)
+	if filter.After == nil {
+		query += " OFFSET ?"
+		args = append(args, (filter.Page-1)*filter.Limit)
+	}
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var blogs []*entity.Blog
+	blogs := []*entity.Blog{}
 	for rows.Next() {
 		var blog entity.Blog
-		if err := rows.Scan(&blog.ID, &blog.Title, &blog.Content, &blog.UserID, &blog.Thumbnail); err != nil {
+		if err := rows.Scan(blogFields(&blog)...); err != nil {
 			return nil, err
 		}
 		blogs = append(blogs, &blog)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return blogs, nil
+	page := &entity.BlogPage{Total: total}
+	if len(blogs) > filter.Limit {
+		blogs = blogs[:filter.Limit]
+		page.HasMore = true
+	}
+	page.Blogs = blogs
+
+	if err := r.loadTags(ctx, blogs); err != nil {
+		return nil, err
+	}
+
+	return page, nil
 }
 
-func (r *BlogRepository) GetByID(id int) (*entity.Blog, error) {
-	row := r.DB.QueryRow("SELECT id, title, content, user_id, thumbnail FROM blogs WHERE id = ?", id)
+func (r *BlogRepository) Search(ctx context.Context, query string, filter *entity.BlogFilter) (*entity.BlogSearchPage, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	match := "MATCH(title, content) AGAINST (? IN NATURAL LANGUAGE MODE)"
+	where, args := filterConditions(filter)
+	where = append(where, match)
+	args = append(args, query)
+
+	var total int
+	if err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM blogs"+whereClause(where), args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	// The relevance score is selected first, so its argument goes in front
+	selectQuery := "SELECT " + blogColumns + ", " + match + " AS score FROM blogs" +
+		whereClause(where) + " ORDER BY score DESC, id DESC LIMIT ? OFFSET ?"
+	selectArgs := append([]interface{}{query}, args...)
+	selectArgs = append(selectArgs, filter.Limit+1, (filter.Page-1)*filter.Limit)
+
+	rows, err := r.DB.QueryContext(ctx, selectQuery, selectArgs...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []*entity.BlogSearchResult{}
+	for rows.Next() {
+		result := &entity.BlogSearchResult{Blog: &entity.Blog{}}
+		if err := rows.Scan(append(blogFields(result.Blog), &result.Score)...); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &entity.BlogSearchPage{Total: total}
+	if len(results) > filter.Limit {
+		results = results[:filter.Limit]
+		page.HasMore = true
+	}
+	page.Results = results
+
+	blogs := make([]*entity.Blog, len(results))
+	for i, result := range results {
+		blogs[i] = result.Blog
+	}
+	if err := r.loadTags(ctx, blogs); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (r *BlogRepository) GetByID(ctx context.Context, id int) (*entity.Blog, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	row := r.DB.QueryRowContext(ctx, "SELECT "+blogColumns+" FROM blogs WHERE id = ?", id)
 
 	var blog entity.Blog
-	if err := row.Scan(&blog.ID, &blog.Title, &blog.Content, &blog.UserID, &blog.Thumbnail); err != nil {
+	if err := row.Scan(blogFields(&blog)...); err != nil {
+		return nil, notFound(err)
+	}
+
+	if err := r.loadTags(ctx, []*entity.Blog{&blog}); err != nil {
 		return nil, err
 	}
 	return &blog, nil
 }
 
-func (r *BlogRepository) Update(blog *entity.Blog) error {
-	_, err := r.DB.Exec("UPDATE blogs SET title = ?, content = ?, thumbnail = ? WHERE id = ?",
-		blog.Title, blog.Content, blog.Thumbnail, blog.ID)
+// Update saves the blog and records the new version as a revision
+func (r *BlogRepository) Update(ctx context.Context, blog *entity.Blog) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Keep the old slug as a redirect when the slug changes
+	var oldSlug string
+	if err := tx.QueryRowContext(ctx, "SELECT COALESCE(slug, '') FROM blogs WHERE id = ? FOR UPDATE", blog.ID).Scan(&oldSlug); err != nil {
+		return notFound(err)
+	}
+	if oldSlug != "" && oldSlug != blog.Slug {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO blog_slug_redirects (slug, blog_id) VALUES (?, ?)", oldSlug, blog.ID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM blog_slug_redirects WHERE slug = ? AND blog_id = ?", blog.Slug, blog.ID); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE blogs SET title = ?, slug = ?, content = ?, thumbnail = ?, category_id = ? WHERE id = ?",
+		blog.Title, blog.Slug, blog.Content, blog.Thumbnail, blog.CategoryID, blog.ID)
+	if err != nil {
+		return duplicateSlug(err)
+	}
+
+	if err := replaceTags(ctx, tx, blog); err != nil {
+		return err
+	}
+
+	if err := insertRevision(ctx, tx, blog); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *BlogRepository) UpdateStatus(ctx context.Context, blog *entity.Blog) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, "UPDATE blogs SET status = ?, publish_at = ?, published_at = ? WHERE id = ?",
+		blog.Status, blog.PublishAt, blog.PublishedAt, blog.ID)
 	return err
 }
 
-func (r *BlogRepository) Delete(id int) error {
-	_, err := r.DB.Exec("DELETE FROM blogs WHERE id = ?", id)
+func (r *BlogRepository) UpdateCommentMode(ctx context.Context, blog *entity.Blog) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, "UPDATE blogs SET comment_mode = ? WHERE id = ?", blog.CommentMode, blog.ID)
 	return err
 }
 
-func (r *BlogRepository) CreateComment(comment *entity.Comment) error {
-	query := `INSERT INTO comments (content, user_id, blog_id) VALUES (?, ?, ?)`
-	_, err := r.DB.Exec(query, comment.Content, comment.UserID, comment.BlogID)
+// PublishDue publishes the scheduled blogs that are due in one conditional
+// UPDATE, so concurrent replicas can never publish the same blog twice
+func (r *BlogRepository) PublishDue(ctx context.Context, now time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, "UPDATE blogs SET status = ?, published_at = publish_at, publish_at = NULL WHERE status = ? AND publish_at <= ?",
+		entity.BlogStatusPublished, entity.BlogStatusScheduled, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *BlogRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, "DELETE FROM blogs WHERE id = ?", id)
 	return err
 }
+
+// blogFields returns the scan destinations matching blogColumns
+func blogFields(blog *entity.Blog) []interface{} {
+	return []interface{}{
+		&blog.ID, &blog.Title, &blog.Slug, &blog.Content, &blog.UserID, &blog.Thumbnail, &blog.CategoryID,
+		&blog.Status, &blog.CommentMode, &blog.PublishAt, &blog.PublishedAt, &blog.CreatedAt,
+	}
+}
+
+// filterConditions builds the WHERE conditions for a blog listing. Only
+// published blogs are visible, plus any blog owned by the viewer.
+func filterConditions(filter *entity.BlogFilter) ([]string, []interface{}) {
+	where := []string{"(status = ? OR user_id = ?)"}
+	args := []interface{}{entity.BlogStatusPublished, filter.ViewerID}
+	if filter.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.UserID != 0 {
+		where = append(where, "user_id = ?")
+		args = append(args, filter.UserID)
+	}
+	if filter.Tag != "" {
+		where = append(where, "id IN (SELECT bt.blog_id FROM blog_tags bt JOIN tags t ON t.id = bt.tag_id WHERE t.name = ?)")
+		args = append(args, filter.Tag)
+	}
+	if filter.CategoryIDs != nil {
+		if len(filter.CategoryIDs) == 0 {
+			where = append(where, "FALSE")
+		} else {
+			where = append(where, "category_id IN ("+placeholders(len(filter.CategoryIDs))+")")
+			for _, id := range filter.CategoryIDs {
+				args = append(args, id)
+			}
+		}
+	}
+	return where, args
+}
+
+// placeholders returns n comma separated query placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
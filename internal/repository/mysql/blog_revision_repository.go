@@ -0,0 +1,56 @@
+package mysql
+
+import (
+	"blog-api/internal/entity"
+	"context"
+	"database/sql"
+)
+
+// insertRevision stores the current state of the blog as its next revision
+func insertRevision(ctx context.Context, tx *sql.Tx, blog *entity.Blog) error {
+	// Lock the blog's revisions so concurrent edits get distinct numbers
+	var next int
+	err := tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(revision), 0) + 1 FROM blog_revisions WHERE blog_id = ? FOR UPDATE", blog.ID).Scan(&next)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO blog_revisions (blog_id, revision, title, content, thumbnail) VALUES (?, ?, ?, ?, ?)",
+		blog.ID, next, blog.Title, blog.Content, blog.Thumbnail)
+	return err
+}
+
+func (r *BlogRepository) GetRevisions(ctx context.Context, blogID int) ([]*entity.BlogRevision, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT id, blog_id, revision, title, content, thumbnail, created_at FROM blog_revisions WHERE blog_id = ? ORDER BY revision DESC", blogID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*entity.BlogRevision{}
+	for rows.Next() {
+		var rev entity.BlogRevision
+		if err := rows.Scan(&rev.ID, &rev.BlogID, &rev.Revision, &rev.Title, &rev.Content, &rev.Thumbnail, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, &rev)
+	}
+
+	return revisions, rows.Err()
+}
+
+func (r *BlogRepository) GetRevision(ctx context.Context, blogID, revision int) (*entity.BlogRevision, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	row := r.DB.QueryRowContext(ctx, "SELECT id, blog_id, revision, title, content, thumbnail, created_at FROM blog_revisions WHERE blog_id = ? AND revision = ?", blogID, revision)
+
+	var rev entity.BlogRevision
+	if err := row.Scan(&rev.ID, &rev.BlogID, &rev.Revision, &rev.Title, &rev.Content, &rev.Thumbnail, &rev.CreatedAt); err != nil {
+		return nil, notFound(err)
+	}
+	return &rev, nil
+}
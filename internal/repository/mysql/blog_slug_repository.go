@@ -0,0 +1,81 @@
+package mysql
+
+import (
+	"blog-api/internal/entity"
+	"context"
+	"database/sql"
+)
+
+// SlugTaken reports whether a slug is used by, or redirects to, a blog other than blogID
+func (r *BlogRepository) SlugTaken(ctx context.Context, slug string, blogID int) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	var taken bool
+	err := r.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM blogs WHERE slug = ? AND id <> ?)
+		OR EXISTS (SELECT 1 FROM blog_slug_redirects WHERE slug = ? AND blog_id <> ?)`,
+		slug, blogID, slug, blogID).Scan(&taken)
+	return taken, err
+}
+
+func (r *BlogRepository) GetBySlug(ctx context.Context, slug string) (*entity.Blog, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	row := r.DB.QueryRowContext(ctx, "SELECT "+blogColumns+" FROM blogs WHERE slug = ?", slug)
+
+	var blog entity.Blog
+	if err := row.Scan(blogFields(&blog)...); err != nil {
+		return nil, notFound(err)
+	}
+
+	if err := r.loadTags(ctx, []*entity.Blog{&blog}); err != nil {
+		return nil, err
+	}
+	return &blog, nil
+}
+
+// GetRedirectSlug returns the current slug of the blog an outdated slug used to belong to
+func (r *BlogRepository) GetRedirectSlug(ctx context.Context, oldSlug string) (string, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	var slug string
+	err := r.DB.QueryRowContext(ctx, "SELECT b.slug FROM blog_slug_redirects s JOIN blogs b ON b.id = s.blog_id WHERE s.slug = ?", oldSlug).Scan(&slug)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return slug, err
+}
+
+// GetWithoutSlug returns the blogs migration 0008 left without a slug
+func (r *BlogRepository) GetWithoutSlug(ctx context.Context) ([]*entity.Blog, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT id, title FROM blogs WHERE slug IS NULL ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blogs := []*entity.Blog{}
+	for rows.Next() {
+		var blog entity.Blog
+		if err := rows.Scan(&blog.ID, &blog.Title); err != nil {
+			return nil, err
+		}
+		blogs = append(blogs, &blog)
+	}
+	return blogs, rows.Err()
+}
+
+// SetSlug only touches blogs still without a slug, so replicas backfilling
+// at the same time leave each other's slugs alone
+func (r *BlogRepository) SetSlug(ctx context.Context, blog *entity.Blog) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, "UPDATE blogs SET slug = ? WHERE id = ? AND slug IS NULL", blog.Slug, blog.ID)
+	return duplicateSlug(err)
+}
@@ -0,0 +1,91 @@
+package mysql
+
+import (
+	"blog-api/internal/entity"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type CategoryRepository struct {
+	DB      *sql.DB
+	Timeout time.Duration
+}
+
+func NewCategoryRepository(db *sql.DB, timeout time.Duration) *CategoryRepository {
+	return &CategoryRepository{DB: db, Timeout: timeout}
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, category *entity.Category) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, "INSERT INTO categories (name, slug, parent_id) VALUES (?, ?, ?)",
+		category.Name, category.Slug, category.ParentID)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	category.ID = int(id)
+	return nil
+}
+
+// GetAll returns every category along with the number of published blogs
+// filed directly under it
+func (r *CategoryRepository) GetAll(ctx context.Context) ([]*entity.Category, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, `SELECT c.id, c.name, c.slug, c.parent_id, COUNT(b.id) FROM categories c
+		LEFT JOIN blogs b ON b.category_id = c.id AND b.status = ?
+		GROUP BY c.id, c.name, c.slug, c.parent_id
+		ORDER BY c.name`, entity.BlogStatusPublished)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*entity.Category{}
+	for rows.Next() {
+		var category entity.Category
+		if err := rows.Scan(&category.ID, &category.Name, &category.Slug, &category.ParentID, &category.PostCount); err != nil {
+			return nil, err
+		}
+		categories = append(categories, &category)
+	}
+	return categories, rows.Err()
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, id int) (*entity.Category, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	row := r.DB.QueryRowContext(ctx, "SELECT id, name, slug, parent_id FROM categories WHERE id = ?", id)
+
+	var category entity.Category
+	if err := row.Scan(&category.ID, &category.Name, &category.Slug, &category.ParentID); err != nil {
+		return nil, notFound(err)
+	}
+	return &category, nil
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, category *entity.Category) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, "UPDATE categories SET name = ?, slug = ?, parent_id = ? WHERE id = ?",
+		category.Name, category.Slug, category.ParentID, category.ID)
+	return err
+}
+
+func (r *CategoryRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, "DELETE FROM categories WHERE id = ?", id)
+	return err
+}
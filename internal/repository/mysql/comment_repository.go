@@ -0,0 +1,192 @@
+package mysql
+
+import (
+	"blog-api/internal/entity"
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// commentColumns lists the columns scanned by commentFields, in the same order
+const commentColumns = "id, content, user_id, blog_id, parent_id, root_id, depth, status, created_at, updated_at"
+
+type CommentRepository struct {
+	DB      *sql.DB
+	Timeout time.Duration
+}
+
+func NewCommentRepository(db *sql.DB, timeout time.Duration) *CommentRepository {
+	return &CommentRepository{DB: db, Timeout: timeout}
+}
+
+func (r *CommentRepository) Create(ctx context.Context, comment *entity.Comment) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	query := `INSERT INTO comments (content, user_id, blog_id, parent_id, root_id, depth, status) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	result, err := r.DB.ExecContext(ctx, query, comment.Content, comment.UserID, comment.BlogID, comment.ParentID, comment.RootID, comment.Depth, comment.Status)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	// Read the comment back for the timestamps the database fills in
+	stored, err := r.GetByID(ctx, int(id))
+	if err != nil {
+		return err
+	}
+	*comment = *stored
+	return nil
+}
+
+// GetByBlog returns a page of the top-level comments on a blog, oldest first.
+// Only approved comments are included, plus the viewer's own.
+func (r *CommentRepository) GetByBlog(ctx context.Context, filter *entity.CommentFilter) (*entity.CommentPage, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	where := "blog_id = ? AND parent_id IS NULL AND (status = ? OR user_id = ?)"
+	args := []interface{}{filter.BlogID, entity.CommentStatusApproved, filter.ViewerID}
+
+	var total int
+	if err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM comments WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra row to find out whether another page follows
+	comments, err := r.query(ctx, "SELECT "+commentColumns+" FROM comments WHERE "+where+" ORDER BY created_at ASC, id ASC LIMIT ? OFFSET ?",
+		append(args, filter.Limit+1, (filter.Page-1)*filter.Limit)...)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &entity.CommentPage{Total: total}
+	if len(comments) > filter.Limit {
+		comments = comments[:filter.Limit]
+		page.HasMore = true
+	}
+	page.Comments = comments
+
+	return page, nil
+}
+
+// GetReplies returns every reply in the threads started by the given
+// top-level comments, oldest first, with a single query. Only approved
+// replies are included, plus the viewer's own.
+func (r *CommentRepository) GetReplies(ctx context.Context, rootIDs []int, viewerID int) ([]*entity.Comment, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	if len(rootIDs) == 0 {
+		return []*entity.Comment{}, nil
+	}
+
+	args := make([]interface{}, 0, len(rootIDs)+2)
+	for _, id := range rootIDs {
+		args = append(args, id)
+	}
+	args = append(args, entity.CommentStatusApproved, viewerID)
+	return r.query(ctx, "SELECT "+commentColumns+" FROM comments WHERE root_id IN ("+placeholders(len(rootIDs))+") AND (status = ? OR user_id = ?) ORDER BY created_at ASC, id ASC", args...)
+}
+
+// GetPending returns a page of the comments awaiting approval, oldest first
+func (r *CommentRepository) GetPending(ctx context.Context, filter *entity.ModerationFilter) (*entity.CommentPage, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	from := " FROM comments c JOIN blogs b ON b.id = c.blog_id WHERE c.status = ?"
+	args := []interface{}{entity.CommentStatusPending}
+	if filter.AuthorID != 0 {
+		from += " AND b.user_id = ?"
+		args = append(args, filter.AuthorID)
+	}
+
+	var total int
+	if err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*)"+from, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	columns := "c." + strings.ReplaceAll(commentColumns, ", ", ", c.")
+	comments, err := r.query(ctx, "SELECT "+columns+from+" ORDER BY c.created_at ASC, c.id ASC LIMIT ? OFFSET ?",
+		append(args, filter.Limit+1, (filter.Page-1)*filter.Limit)...)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &entity.CommentPage{Total: total}
+	if len(comments) > filter.Limit {
+		comments = comments[:filter.Limit]
+		page.HasMore = true
+	}
+	page.Comments = comments
+
+	return page, nil
+}
+
+func (r *CommentRepository) GetByID(ctx context.Context, id int) (*entity.Comment, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	row := r.DB.QueryRowContext(ctx, "SELECT "+commentColumns+" FROM comments WHERE id = ?", id)
+
+	var comment entity.Comment
+	if err := row.Scan(commentFields(&comment)...); err != nil {
+		return nil, notFound(err)
+	}
+	return &comment, nil
+}
+
+func (r *CommentRepository) Update(ctx context.Context, comment *entity.Comment) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, "UPDATE comments SET content = ?, status = ? WHERE id = ?", comment.Content, comment.Status, comment.ID)
+	return err
+}
+
+func (r *CommentRepository) UpdateStatus(ctx context.Context, comment *entity.Comment) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, "UPDATE comments SET status = ? WHERE id = ?", comment.Status, comment.ID)
+	return err
+}
+
+func (r *CommentRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, "DELETE FROM comments WHERE id = ?", id)
+	return err
+}
+
+func (r *CommentRepository) query(ctx context.Context, query string, args ...interface{}) ([]*entity.Comment, error) {
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []*entity.Comment{}
+	for rows.Next() {
+		var comment entity.Comment
+		if err := rows.Scan(commentFields(&comment)...); err != nil {
+			return nil, err
+		}
+		comments = append(comments, &comment)
+	}
+	return comments, rows.Err()
+}
+
+// commentFields returns the scan destinations matching commentColumns
+func commentFields(comment *entity.Comment) []interface{} {
+	return []interface{}{
+		&comment.ID, &comment.Content, &comment.UserID, &comment.BlogID,
+		&comment.ParentID, &comment.RootID, &comment.Depth, &comment.Status, &comment.CreatedAt, &comment.UpdatedAt,
+	}
+}
@@ -0,0 +1,32 @@
+package mysql
+
+import (
+	"blog-api/internal/repository"
+	"database/sql"
+	"errors"
+	"strings"
+
+	driver "github.com/go-sql-driver/mysql"
+)
+
+// errDuplicateEntry is the MySQL error number of unique key violations
+const errDuplicateEntry = 1062
+
+// notFound turns sql.ErrNoRows into repository.ErrNotFound so callers do
+// not depend on database/sql
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrNotFound
+	}
+	return err
+}
+
+// duplicateSlug turns a violation of the unique blog slug key into
+// repository.ErrDuplicateSlug
+func duplicateSlug(err error) error {
+	var mysqlErr *driver.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errDuplicateEntry && strings.Contains(mysqlErr.Message, "uq_blogs_slug") {
+		return repository.ErrDuplicateSlug
+	}
+	return err
+}
@@ -0,0 +1,218 @@
+package mysql
+
+import (
+	"blog-api/internal/entity"
+	"context"
+	"database/sql"
+	"time"
+)
+
+const mediaColumns = "id, user_id, path, content_type, width, height, size, created_at"
+
+type MediaRepository struct {
+	DB      *sql.DB
+	Timeout time.Duration
+}
+
+func NewMediaRepository(db *sql.DB, timeout time.Duration) *MediaRepository {
+	return &MediaRepository{DB: db, Timeout: timeout}
+}
+
+// Create adds an image to a user's library. Uploading the same image twice
+// returns the existing item instead of adding a duplicate.
+func (r *MediaRepository) Create(ctx context.Context, media *entity.Media) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, `INSERT INTO media (user_id, path, content_type, width, height, size) VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)`,
+		media.UserID, media.Path, media.ContentType, media.Width, media.Height, media.Size)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	stored, err := r.GetByID(ctx, int(id))
+	if err != nil {
+		return err
+	}
+	*media = *stored
+	return nil
+}
+
+// GetByUser returns a page of a user's library, newest first
+func (r *MediaRepository) GetByUser(ctx context.Context, filter *entity.MediaFilter) (*entity.MediaPage, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	var total int
+	if err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM media WHERE user_id = ?", filter.UserID).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT "+mediaColumns+" FROM media WHERE user_id = ? ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?",
+		filter.UserID, filter.Limit+1, (filter.Page-1)*filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*entity.Media{}
+	for rows.Next() {
+		var media entity.Media
+		if err := rows.Scan(mediaFields(&media)...); err != nil {
+			return nil, err
+		}
+		items = append(items, &media)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &entity.MediaPage{Total: total}
+	if len(items) > filter.Limit {
+		items = items[:filter.Limit]
+		page.HasMore = true
+	}
+	page.Media = items
+
+	return page, nil
+}
+
+func (r *MediaRepository) GetByID(ctx context.Context, id int) (*entity.Media, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	row := r.DB.QueryRowContext(ctx, "SELECT "+mediaColumns+" FROM media WHERE id = ?", id)
+
+	var media entity.Media
+	if err := row.Scan(mediaFields(&media)...); err != nil {
+		return nil, notFound(err)
+	}
+	return &media, nil
+}
+
+// UsedByUser reports whether any of a user's blogs shows the file as its
+// thumbnail or embeds it in its content
+func (r *MediaRepository) UsedByUser(ctx context.Context, path string, userID int) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	var used bool
+	err := r.DB.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM blogs WHERE user_id = ? AND (thumbnail = ? OR LOCATE(?, content) > 0))",
+		userID, path, path).Scan(&used)
+	return used, err
+}
+
+// fileInUseQuery reports whether a stored file is still needed: shown as a
+// thumbnail, embedded in content, kept by a revision that can be restored
+// or kept in someone's media library
+const fileInUseQuery = `SELECT EXISTS (SELECT 1 FROM blogs WHERE thumbnail = ? OR LOCATE(?, content) > 0)
+	OR EXISTS (SELECT 1 FROM blog_revisions WHERE thumbnail = ?)
+	OR EXISTS (SELECT 1 FROM media WHERE path = ?)`
+
+// FileInUse reports whether anything still needs a stored file
+func (r *MediaRepository) FileInUse(ctx context.Context, path string) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	var inUse bool
+	err := r.DB.QueryRowContext(ctx, fileInUseQuery, path, path, path, path).Scan(&inUse)
+	return inUse, err
+}
+
+func (r *MediaRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, "DELETE FROM media WHERE id = ?", id)
+	return err
+}
+
+// ScheduleCleanup has a stored file checked for removal at dueAt. A file
+// that is already scheduled keeps the later of both times.
+func (r *MediaRepository) ScheduleCleanup(ctx context.Context, path string, dueAt time.Time) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, `INSERT INTO file_cleanups (path, due_at) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE due_at = GREATEST(due_at, VALUES(due_at))`, path, dueAt)
+	return err
+}
+
+// DueCleanups returns the files due for removal, longest overdue first
+func (r *MediaRepository) DueCleanups(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT path FROM file_cleanups WHERE due_at <= ? ORDER BY due_at LIMIT ?", now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
+
+// CleanUp calls remove for a file that is due and no longer in use, and
+// reports whether it did. The scheduled row stays locked meanwhile, so an
+// upload of the same file, which schedules it again first, waits until the
+// file is gone and then stores it anew. Files that are still used are
+// dropped from the schedule; a failed removal is retried on the next run.
+func (r *MediaRepository) CleanUp(ctx context.Context, path string, now time.Time, remove func(ctx context.Context) error) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var dueAt time.Time
+	err = tx.QueryRowContext(ctx, "SELECT due_at FROM file_cleanups WHERE path = ? FOR UPDATE", path).Scan(&dueAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	// Uploaded again since it was listed
+	if dueAt.After(now) {
+		return false, nil
+	}
+
+	var inUse bool
+	if err := tx.QueryRowContext(ctx, fileInUseQuery, path, path, path, path).Scan(&inUse); err != nil {
+		return false, err
+	}
+	if !inUse {
+		if err := remove(ctx); err != nil {
+			return false, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM file_cleanups WHERE path = ?", path); err != nil {
+		return false, err
+	}
+	return !inUse, tx.Commit()
+}
+
+// mediaFields returns the scan destinations matching mediaColumns
+func mediaFields(media *entity.Media) []interface{} {
+	return []interface{}{
+		&media.ID, &media.UserID, &media.Path, &media.ContentType, &media.Width, &media.Height, &media.Size, &media.CreatedAt,
+	}
+}
@@ -0,0 +1,97 @@
+package mysql
+
+import (
+	"blog-api/internal/entity"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// signingKeysLock names the advisory lock rotations hold, per database
+const signingKeysLock = "CONCAT('signing_keys:', DATABASE())"
+
+type SigningKeyRepository struct {
+	DB      *sql.DB
+	Timeout time.Duration
+}
+
+func NewSigningKeyRepository(db *sql.DB, timeout time.Duration) *SigningKeyRepository {
+	return &SigningKeyRepository{DB: db, Timeout: timeout}
+}
+
+// LockSigningKeys takes a MySQL advisory lock shared by every replica,
+// waiting up to the query timeout for another replica to release it
+func (r *SigningKeyRepository) LockSigningKeys(ctx context.Context) (func(), error) {
+	// Advisory locks belong to a session, so the lock keeps its connection
+	conn, err := r.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var locked sql.NullInt64
+	err = conn.QueryRowContext(ctx, "SELECT GET_LOCK("+signingKeysLock+", ?)", int(r.Timeout.Seconds())).Scan(&locked)
+	if err == nil && locked.Int64 != 1 {
+		err = errors.New("timed out waiting for another replica to rotate the signing keys")
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return func() {
+		conn.ExecContext(context.WithoutCancel(ctx), "DO RELEASE_LOCK("+signingKeysLock+")")
+		conn.Close()
+	}, nil
+}
+
+func (r *SigningKeyRepository) ListSigningKeys(ctx context.Context) ([]*entity.SigningKey, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT id, algorithm, private_key, not_before, not_after, created_at FROM signing_keys ORDER BY not_before")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*entity.SigningKey
+	for rows.Next() {
+		var key entity.SigningKey
+		if err := rows.Scan(&key.ID, &key.Algorithm, &key.PrivateKey, &key.NotBefore, &key.NotAfter, &key.CreatedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, &key)
+	}
+	return keys, rows.Err()
+}
+
+// RotateSigningKey stores a new key and retires the current ones in one
+// transaction
+func (r *SigningKeyRepository) RotateSigningKey(ctx context.Context, key *entity.SigningKey, retireAt time.Time) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "UPDATE signing_keys SET not_after = ? WHERE not_after IS NULL", retireAt); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO signing_keys (id, algorithm, private_key, not_before) VALUES (?, ?, ?, ?)",
+		key.ID, key.Algorithm, key.PrivateKey, key.NotBefore); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SigningKeyRepository) DeleteExpiredSigningKeys(ctx context.Context, now time.Time) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, "DELETE FROM signing_keys WHERE not_after <= ?", now)
+	return err
+}
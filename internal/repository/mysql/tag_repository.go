@@ -0,0 +1,90 @@
+package mysql
+
+import (
+	"blog-api/internal/entity"
+	"context"
+	"database/sql"
+)
+
+// replaceTags replaces the tags of a blog, creating tags that do not exist yet
+func replaceTags(ctx context.Context, tx *sql.Tx, blog *entity.Blog) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM blog_tags WHERE blog_id = ?", blog.ID); err != nil {
+		return err
+	}
+
+	for _, name := range blog.Tags {
+		// LAST_INSERT_ID(id) makes an existing tag report its own ID
+		result, err := tx.ExecContext(ctx, "INSERT INTO tags (name) VALUES (?) ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)", name)
+		if err != nil {
+			return err
+		}
+		tagID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "INSERT INTO blog_tags (blog_id, tag_id) VALUES (?, ?)", blog.ID, tagID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadTags fills in the tags of all given blogs with a single query
+func (r *BlogRepository) loadTags(ctx context.Context, blogs []*entity.Blog) error {
+	if len(blogs) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*entity.Blog, len(blogs))
+	args := make([]interface{}, len(blogs))
+	for i, blog := range blogs {
+		blog.Tags = []string{}
+		byID[blog.ID] = blog
+		args[i] = blog.ID
+	}
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT bt.blog_id, t.name FROM blog_tags bt JOIN tags t ON t.id = bt.tag_id WHERE bt.blog_id IN ("+placeholders(len(args))+") ORDER BY t.name", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var blogID int
+		var name string
+		if err := rows.Scan(&blogID, &name); err != nil {
+			return err
+		}
+		if blog, ok := byID[blogID]; ok {
+			blog.Tags = append(blog.Tags, name)
+		}
+	}
+	return rows.Err()
+}
+
+// GetTags returns every tag used by a published blog, most used first
+func (r *BlogRepository) GetTags(ctx context.Context) ([]*entity.Tag, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, `SELECT t.name, COUNT(*) AS post_count FROM tags t
+		JOIN blog_tags bt ON bt.tag_id = t.id
+		JOIN blogs b ON b.id = bt.blog_id AND b.status = ?
+		GROUP BY t.id, t.name
+		ORDER BY post_count DESC, t.name`, entity.BlogStatusPublished)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*entity.Tag{}
+	for rows.Next() {
+		var tag entity.Tag
+		if err := rows.Scan(&tag.Name, &tag.PostCount); err != nil {
+			return nil, err
+		}
+		tags = append(tags, &tag)
+	}
+	return tags, rows.Err()
+}
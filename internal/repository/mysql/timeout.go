@@ -0,0 +1,16 @@
+package mysql
+
+import (
+	"context"
+	"time"
+)
+
+// withTimeout bounds a repository call, and every query it runs, by the
+// configured timeout. Cancelling ctx, e.g. when the client disconnects,
+// aborts the call either way.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,103 @@
+package mysql
+
+import (
+	"blog-api/internal/entity"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type TokenRepository struct {
+	DB      *sql.DB
+	Timeout time.Duration
+}
+
+func NewTokenRepository(db *sql.DB, timeout time.Duration) *TokenRepository {
+	return &TokenRepository{DB: db, Timeout: timeout}
+}
+
+func (r *TokenRepository) CreateRefreshToken(ctx context.Context, token *entity.RefreshToken) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, "INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at) VALUES (?, ?, ?, ?)",
+		token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	token.ID = int(id)
+	return nil
+}
+
+func (r *TokenRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	row := r.DB.QueryRowContext(ctx, "SELECT id, user_id, family_id, token_hash, expires_at, used_at, revoked_at, created_at FROM refresh_tokens WHERE token_hash = ?", tokenHash)
+
+	var token entity.RefreshToken
+	if err := row.Scan(&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash, &token.ExpiresAt, &token.UsedAt, &token.RevokedAt, &token.CreatedAt); err != nil {
+		return nil, notFound(err)
+	}
+	return &token, nil
+}
+
+// UseRefreshToken marks a refresh token as used in one conditional UPDATE and
+// reports whether it was still unused, so concurrent refreshes with the same
+// token cannot both succeed
+func (r *TokenRepository) UseRefreshToken(ctx context.Context, id int) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, "UPDATE refresh_tokens SET used_at = CURRENT_TIMESTAMP WHERE id = ? AND used_at IS NULL AND revoked_at IS NULL", id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+// RevokeFamily revokes every refresh token of a login session
+func (r *TokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE family_id = ? AND revoked_at IS NULL", familyID)
+	return err
+}
+
+// RevokeUserFamilies revokes the refresh tokens of every session of a user
+func (r *TokenRepository) RevokeUserFamilies(ctx context.Context, userID int) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = ? AND revoked_at IS NULL", userID)
+	return err
+}
+
+// RevokeAccessToken adds an access token to the denylist until it expires.
+// Entries that are no longer needed are cleared on the way.
+func (r *TokenRepository) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	if _, err := r.DB.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at < ?", time.Now()); err != nil {
+		return err
+	}
+	_, err := r.DB.ExecContext(ctx, "INSERT IGNORE INTO revoked_tokens (jti, expires_at) VALUES (?, ?)", tokenID, expiresAt)
+	return err
+}
+
+func (r *TokenRepository) IsAccessTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	var revoked bool
+	err := r.DB.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = ?)", tokenID).Scan(&revoked)
+	return revoked, err
+}
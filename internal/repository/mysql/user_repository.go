@@ -2,57 +2,96 @@ package mysql
 
 import (
 	"blog-api/internal/entity"
+	"context"
 	"database/sql"
+	"time"
 )
 
 type UserRepository struct {
-	DB *sql.DB
+	DB      *sql.DB
+	Timeout time.Duration
 }
 
-func NewUserRepository(db *sql.DB) *UserRepository {
-	return &UserRepository{DB: db}
+func NewUserRepository(db *sql.DB, timeout time.Duration) *UserRepository {
+	return &UserRepository{DB: db, Timeout: timeout}
 }
 
-func (r *UserRepository) Create(user *entity.User) error {
-	_, err := r.DB.Exec("INSERT INTO users (username, password, email, role) VALUES(?, ?, ?, ?)",
+func (r *UserRepository) Create(ctx context.Context, user *entity.User) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, "INSERT INTO users (username, password, email, role) VALUES(?, ?, ?, ?)",
 		user.Username, user.Password, user.Email, user.Role)
-	return err
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	user.ID = int(id)
+	return nil
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id int) (*entity.User, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	row := r.DB.QueryRowContext(ctx, "SELECT id, username, email, role FROM users WHERE id = ?", id)
+
+	var user entity.User
+	if err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Role); err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
 }
 
-func (r *UserRepository) GetByUsernameAndPassword(username, password string) (*entity.User, error) {
-	row := r.DB.QueryRow("SELECT id, username, email, role FROM users WHERE username = ? AND password = ?",
+func (r *UserRepository) GetByUsernameAndPassword(ctx context.Context, username, password string) (*entity.User, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	row := r.DB.QueryRowContext(ctx, "SELECT id, username, email, role FROM users WHERE username = ? AND password = ?",
 		username, password)
 
 	var user entity.User
 	if err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Role); err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) GetByUsernameOrEmail(username, email string) (*entity.User, error) {
-	row := r.DB.QueryRow("SELECT id, username, email, role FROM users WHERE username = ? OR email = ?",
+func (r *UserRepository) GetByUsernameOrEmail(ctx context.Context, username, email string) (*entity.User, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	row := r.DB.QueryRowContext(ctx, "SELECT id, username, email, role FROM users WHERE username = ? OR email = ?",
 		username, email)
 
 	var user entity.User
 	if err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Role); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) GetByUsername(username string) (*entity.User, error) {
-	row := r.DB.QueryRow("SELECT id, username, password, email, role FROM users WHERE username = ?", username)
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	row := r.DB.QueryRowContext(ctx, "SELECT id, username, password, email, role FROM users WHERE username = ?", username)
 
 	var user entity.User
 	if err := row.Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.Role); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &user, nil
 }
+
+func (r *UserRepository) UpdateRole(ctx context.Context, id int, role string) error {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, "UPDATE users SET role = ? WHERE id = ?", role, id)
+	return err
+}
@@ -2,55 +2,640 @@ package usecase
 
 import (
 	"blog-api/internal/entity"
-	repoMysql "blog-api/internal/repository/mysql"
+	"blog-api/internal/repository"
+	"blog-api/pkg/auth"
+	"blog-api/pkg/storage"
+	"blog-api/pkg/upload"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
+const (
+	DefaultPageLimit = 10
+	MaxPageLimit     = 100
+
+	maxTags      = 10
+	maxTagLength = 64
+
+	maxSlugLength = 200
+	// maxSlugAttempts bounds how often a save is retried when concurrent
+	// saves take the slug first
+	maxSlugAttempts = 3
+)
+
+var (
+	// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+	ErrInvalidCursor = validationError("cursor", "invalid cursor")
+
+	// ErrBlogNotFound is returned when a blog does not exist or is hidden from the caller
+	ErrBlogNotFound = notFoundError("blog not found")
+
+	// ErrNotBlogOwner is returned when a user acts on a blog written by someone else
+	ErrNotBlogOwner = forbiddenError("only the author of the blog can do this")
+
+	// ErrInvalidStatus is returned for unknown statuses
+	ErrInvalidStatus = validationError("status", "invalid blog status")
+
+	// ErrStatusTransition is returned when a blog may not move from its current status to the requested one
+	ErrStatusTransition = conflictError("invalid blog status change")
+
+	// ErrSlugConflict is returned when concurrent saves keep taking the slug a blog would get
+	ErrSlugConflict = conflictError("another blog with this title was saved at the same time, please retry")
+
+	// ErrRevisionNotFound is returned when a blog has no revision with the requested number
+	ErrRevisionNotFound = notFoundError("revision not found")
+
+	// ErrInvalidTag is returned for tags that are too long
+	ErrInvalidTag = validationError("tags", fmt.Sprintf("tags must be at most %d characters", maxTagLength))
+
+	// ErrTooManyTags is returned when a blog is given more than maxTags tags
+	ErrTooManyTags = validationError("tags", fmt.Sprintf("a blog can have at most %d tags", maxTags))
+
+	// ErrInvalidCommentMode is returned for comment modes other than open, moderated and closed
+	ErrInvalidCommentMode = validationError("comment_mode", "comment mode must be one of open, moderated or closed")
+
+	// ErrPublishAtInPast is returned when a blog is scheduled for a time that has already passed
+	ErrPublishAtInPast = validationError("publish_at", "publish_at must be in the future")
+
+	// ErrInvalidThumbnail is returned when an uploaded thumbnail is not an acceptable image
+	ErrInvalidThumbnail = validationError("thumbnail", "invalid thumbnail")
+)
+
+// blogTransitions lists the statuses a blog may move to from each status
+var blogTransitions = map[string][]string{
+	entity.BlogStatusDraft:     {entity.BlogStatusPublished, entity.BlogStatusScheduled, entity.BlogStatusArchived},
+	entity.BlogStatusScheduled: {entity.BlogStatusPublished, entity.BlogStatusScheduled, entity.BlogStatusDraft, entity.BlogStatusArchived},
+	entity.BlogStatusPublished: {entity.BlogStatusDraft, entity.BlogStatusArchived},
+	entity.BlogStatusArchived:  {entity.BlogStatusDraft},
+}
+
 type BlogUsecase interface {
-	Create(blog *entity.Blog) error
-	GetAll() ([]*entity.Blog, error)
-	GetByID(id int) (*entity.Blog, error)
-	Update(blog *entity.Blog) error
-	Delete(id int) error
-	CreateComment(comment *entity.Comment) error
+	Create(ctx context.Context, blog *entity.Blog) error
+	GetAll(ctx context.Context, filter *entity.BlogFilter) (*entity.BlogPage, error)
+	Search(ctx context.Context, query string, filter *entity.BlogFilter) (*entity.BlogSearchPage, error)
+	GetByID(ctx context.Context, id int) (*entity.Blog, error)
+	GetVisibleByID(ctx context.Context, id, viewerID int) (*entity.Blog, error)
+	GetVisibleBySlug(ctx context.Context, slug string, viewerID int) (blog *entity.Blog, canonicalSlug string, err error)
+	ChangeStatus(ctx context.Context, id int, actor *auth.Principal, status string) (*entity.Blog, error)
+	Schedule(ctx context.Context, id int, actor *auth.Principal, publishAt time.Time) (*entity.Blog, error)
+	SetCommentMode(ctx context.Context, id int, actor *auth.Principal, mode string) (*entity.Blog, error)
+	PublishDue(ctx context.Context, now time.Time) (int64, error)
+	Update(ctx context.Context, blog *entity.Blog, actor *auth.Principal) error
+	GetRevisions(ctx context.Context, blogID int, actor *auth.Principal) ([]*entity.BlogRevision, error)
+	DiffRevisions(ctx context.Context, blogID int, actor *auth.Principal, from, to int) (*entity.RevisionDiff, error)
+	RestoreRevision(ctx context.Context, blogID int, actor *auth.Principal, revision int) (*entity.Blog, error)
+	Delete(ctx context.Context, id int, actor *auth.Principal) error
+	GetTags(ctx context.Context) ([]*entity.Tag, error)
+	SaveThumbnail(ctx context.Context, src io.Reader) (string, error)
+	MediaThumbnail(ctx context.Context, mediaID, userID int) (string, error)
+	DiscardThumbnail(ctx context.Context, path string)
+	BackfillSlugs(ctx context.Context) (int, error)
 }
 
 type blogUsecase struct {
-	blogRepo *repoMysql.BlogRepository
+	blogRepo     BlogRepository
+	categoryRepo CategoryRepository
+	mediaRepo    MediaRepository
+	storage      storage.Storage
 }
 
-// CreateComment implements BlogUsecase.
-func (u *blogUsecase) CreateComment(comment *entity.Comment) error {
-	return u.blogRepo.CreateComment(comment)
+func NewBlogUsecase(blogRepo BlogRepository, categoryRepo CategoryRepository, mediaRepo MediaRepository, store storage.Storage) BlogUsecase {
+	return &blogUsecase{
+		blogRepo:     blogRepo,
+		categoryRepo: categoryRepo,
+		mediaRepo:    mediaRepo,
+		storage:      store,
+	}
 }
 
-func NewBlogUsecase(blogRepo *repoMysql.BlogRepository) BlogUsecase {
-	return &blogUsecase{blogRepo: blogRepo}
+func (u *blogUsecase) Create(ctx context.Context, blog *entity.Blog) error {
+	// New blogs start as drafts unless the author publishes or schedules them right away
+	if blog.PublishAt != nil {
+		if !blog.PublishAt.After(time.Now()) {
+			return ErrPublishAtInPast
+		}
+		if blog.Status != "" && blog.Status != entity.BlogStatusScheduled {
+			return ErrInvalidStatus
+		}
+		blog.Status = entity.BlogStatusScheduled
+	}
+
+	if err := u.prepare(ctx, blog); err != nil {
+		return err
+	}
+
+	if blog.CommentMode == "" {
+		blog.CommentMode = entity.CommentModeOpen
+	}
+	if !validCommentMode(blog.CommentMode) {
+		return ErrInvalidCommentMode
+	}
+
+	switch blog.Status {
+	case "":
+		blog.Status = entity.BlogStatusDraft
+	case entity.BlogStatusDraft, entity.BlogStatusScheduled:
+	case entity.BlogStatusPublished:
+		now := time.Now()
+		blog.PublishedAt = &now
+	default:
+		return ErrInvalidStatus
+	}
+
+	return u.saveWithSlug(ctx, blog, u.blogRepo.Create)
 }
 
-func (u *blogUsecase) Create(blog *entity.Blog) error {
-	return u.blogRepo.Create(blog)
+func (u *blogUsecase) GetAll(ctx context.Context, filter *entity.BlogFilter) (*entity.BlogPage, error) {
+	normalizePage(&filter.Page, &filter.Limit)
+	if err := u.resolveCategory(ctx, filter); err != nil {
+		return nil, err
+	}
+	if filter.Sort == "" {
+		filter.Sort = entity.BlogSortNewest
+	}
+
+	if filter.Cursor != "" {
+		after, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		filter.After = after
+	}
+
+	page, err := u.blogRepo.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hand out a cursor pointing at the last blog so clients can keep scrolling
+	if page.HasMore && len(page.Blogs) > 0 {
+		last := page.Blogs[len(page.Blogs)-1]
+		cursor := &entity.BlogCursor{ID: last.ID}
+		if filter.Sort == entity.BlogSortTitle {
+			cursor.Title = last.Title
+		} else {
+			cursor.CreatedAt = last.CreatedAt
+		}
+		page.NextCursor = encodeCursor(cursor)
+	}
+
+	return page, nil
 }
 
-func (u *blogUsecase) GetAll() ([]*entity.Blog, error) {
-	return u.blogRepo.GetAll()
+func (u *blogUsecase) Search(ctx context.Context, query string, filter *entity.BlogFilter) (*entity.BlogSearchPage, error) {
+	normalizePage(&filter.Page, &filter.Limit)
+	if err := u.resolveCategory(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	page, err := u.blogRepo.Search(ctx, query, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Show where the query matched instead of the whole content
+	terms := searchTerms(query)
+	for _, result := range page.Results {
+		result.Snippet = highlightSnippet(result.Content, terms)
+	}
+
+	return page, nil
 }
 
-func (u *blogUsecase) GetByID(id int) (*entity.Blog, error) {
-	return u.blogRepo.GetByID(id)
+func (u *blogUsecase) GetByID(ctx context.Context, id int) (*entity.Blog, error) {
+	blog, err := u.blogRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, orNotFound(err, ErrBlogNotFound)
+	}
+	return blog, nil
 }
 
-func (u *blogUsecase) Update(blog *entity.Blog) error {
-	return u.blogRepo.Update(blog)
+// GetVisibleByID returns a blog only if it is published or written by the viewer
+func (u *blogUsecase) GetVisibleByID(ctx context.Context, id, viewerID int) (*entity.Blog, error) {
+	blog, err := u.blogRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, orNotFound(err, ErrBlogNotFound)
+	}
+
+	if !canView(blog, viewerID) {
+		return nil, ErrBlogNotFound
+	}
+	return blog, nil
+}
+
+// GetVisibleBySlug looks a blog up by slug. When the slug is outdated no blog
+// is returned, only the current slug the caller should be redirected to.
+func (u *blogUsecase) GetVisibleBySlug(ctx context.Context, slug string, viewerID int) (*entity.Blog, string, error) {
+	blog, err := u.blogRepo.GetBySlug(ctx, slug)
+	if err == nil {
+		if !canView(blog, viewerID) {
+			return nil, "", ErrBlogNotFound
+		}
+		return blog, blog.Slug, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, "", err
+	}
+
+	canonical, err := u.blogRepo.GetRedirectSlug(ctx, slug)
+	if err != nil {
+		return nil, "", err
+	}
+	if canonical == "" {
+		return nil, "", ErrBlogNotFound
+	}
+
+	// Only redirect to blogs the caller is allowed to see
+	blog, err = u.blogRepo.GetBySlug(ctx, canonical)
+	if err != nil {
+		return nil, "", orNotFound(err, ErrBlogNotFound)
+	}
+	if !canView(blog, viewerID) {
+		return nil, "", ErrBlogNotFound
+	}
+	return nil, canonical, nil
+}
+
+// ChangeStatus moves a blog to a new status if the transition is allowed
+func (u *blogUsecase) ChangeStatus(ctx context.Context, id int, actor *auth.Principal, status string) (*entity.Blog, error) {
+	return u.transition(ctx, id, actor, status, nil)
+}
+
+// Schedule sets a draft (or already scheduled) blog to go live at publishAt
+func (u *blogUsecase) Schedule(ctx context.Context, id int, actor *auth.Principal, publishAt time.Time) (*entity.Blog, error) {
+	if !publishAt.After(time.Now()) {
+		return nil, ErrPublishAtInPast
+	}
+	return u.transition(ctx, id, actor, entity.BlogStatusScheduled, &publishAt)
+}
+
+// SetCommentMode opens, moderates or closes the comments on a blog
+func (u *blogUsecase) SetCommentMode(ctx context.Context, id int, actor *auth.Principal, mode string) (*entity.Blog, error) {
+	if !validCommentMode(mode) {
+		return nil, ErrInvalidCommentMode
+	}
+
+	blog, err := u.getOwnBlog(ctx, id, actor, auth.PermBlogUpdateAny)
+	if err != nil {
+		return nil, err
+	}
+
+	blog.CommentMode = mode
+	if err := u.blogRepo.UpdateCommentMode(ctx, blog); err != nil {
+		return nil, err
+	}
+	return blog, nil
+}
+
+// PublishDue publishes every scheduled blog whose publication time has come
+func (u *blogUsecase) PublishDue(ctx context.Context, now time.Time) (int64, error) {
+	return u.blogRepo.PublishDue(ctx, now)
+}
+
+func (u *blogUsecase) transition(ctx context.Context, id int, actor *auth.Principal, status string, publishAt *time.Time) (*entity.Blog, error) {
+	blog, err := u.getOwnBlog(ctx, id, actor, auth.PermBlogUpdateAny)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := false
+	for _, next := range blogTransitions[blog.Status] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: cannot move a %s blog to %s", ErrStatusTransition, blog.Status, status)
+	}
+
+	// Keep the original publication time when a blog is republished
+	blog.Status = status
+	blog.PublishAt = publishAt
+	if status == entity.BlogStatusPublished && blog.PublishedAt == nil {
+		now := time.Now()
+		blog.PublishedAt = &now
+	}
+
+	if err := u.blogRepo.UpdateStatus(ctx, blog); err != nil {
+		return nil, err
+	}
+	return blog, nil
+}
+
+// Update saves the changes to a blog; only its author and admins may do so
+func (u *blogUsecase) Update(ctx context.Context, blog *entity.Blog, actor *auth.Principal) error {
+	if _, err := u.getOwnBlog(ctx, blog.ID, actor, auth.PermBlogUpdateAny); err != nil {
+		return err
+	}
+
+	if err := u.prepare(ctx, blog); err != nil {
+		return err
+	}
+
+	// The replaced thumbnail is kept, the revision that shows it can still
+	// be restored
+	return u.saveWithSlug(ctx, blog, u.blogRepo.Update)
 }
 
-func (u *blogUsecase) Delete(id int) error {
-	// Check if the blog exists before attempting to delete it
-	_, err := u.blogRepo.GetByID(id)
+// SaveThumbnail validates and stores an uploaded thumbnail along with its
+// resized variants, and returns its key
+func (u *blogUsecase) SaveThumbnail(ctx context.Context, src io.Reader) (string, error) {
+	image, err := upload.SaveImage(ctx, u.storage, src, holdFile(u.mediaRepo))
 	if err != nil {
-		return fmt.Errorf("blog with ID %d not found", id)
+		if isImageError(err) {
+			return "", fmt.Errorf("%w: %v", ErrInvalidThumbnail, err)
+		}
+		return "", err
+	}
+	return image.Key, nil
+}
+
+// MediaThumbnail returns the path of an item in the user's media library,
+// to be used as a blog's thumbnail
+func (u *blogUsecase) MediaThumbnail(ctx context.Context, mediaID, userID int) (string, error) {
+	media, err := u.mediaRepo.GetByID(ctx, mediaID)
+	if err != nil {
+		return "", orNotFound(err, invalid("thumbnail_media_id", ErrMediaNotFound))
+	}
+	if media.UserID != userID {
+		return "", invalid("thumbnail_media_id", ErrMediaNotFound)
+	}
+	return media.Path, nil
+}
+
+// DiscardThumbnail schedules the removal of a stored thumbnail, which
+// happens once nothing uses it anymore. Files are content addressed, so
+// several blogs and media library items may share one file.
+func (u *blogUsecase) DiscardThumbnail(ctx context.Context, path string) {
+	discardFile(ctx, u.mediaRepo, path)
+}
+
+// GetTags returns the tags in use along with how many published blogs carry them
+func (u *blogUsecase) GetTags(ctx context.Context) ([]*entity.Tag, error) {
+	return u.blogRepo.GetTags(ctx)
+}
+
+// prepare normalizes the tags of a blog and checks that its category exists
+func (u *blogUsecase) prepare(ctx context.Context, blog *entity.Blog) error {
+	tags, err := normalizeTags(blog.Tags)
+	if err != nil {
+		return err
+	}
+	blog.Tags = tags
+
+	if blog.CategoryID != nil {
+		if _, err := u.categoryRepo.GetByID(ctx, *blog.CategoryID); err != nil {
+			return orNotFound(err, invalid("category_id", ErrCategoryNotFound))
+		}
+	}
+	return nil
+}
+
+// saveWithSlug derives the slug of a blog from its title and saves the blog.
+// A concurrent save may take the same slug between the check and the save,
+// so the slug is derived again when the database rejects it.
+func (u *blogUsecase) saveWithSlug(ctx context.Context, blog *entity.Blog, save func(context.Context, *entity.Blog) error) error {
+	for attempt := 1; ; attempt++ {
+		slug, err := u.uniqueSlug(ctx, blog.Title, blog.ID)
+		if err != nil {
+			return err
+		}
+		blog.Slug = slug
+
+		err = save(ctx, blog)
+		if !errors.Is(err, repository.ErrDuplicateSlug) {
+			return err
+		}
+		if attempt == maxSlugAttempts {
+			return ErrSlugConflict
+		}
+	}
+}
+
+// BackfillSlugs gives the blogs written before slugs existed the slug their
+// title would get today, oldest blog first, and returns how many it gave one
+func (u *blogUsecase) BackfillSlugs(ctx context.Context) (int, error) {
+	blogs, err := u.blogRepo.GetWithoutSlug(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i, blog := range blogs {
+		if err := u.saveWithSlug(ctx, blog, u.blogRepo.SetSlug); err != nil {
+			return i, err
+		}
+	}
+	return len(blogs), nil
+}
+
+// uniqueSlug slugifies a title and appends -2, -3, ... until the slug is not
+// used by any other blog, including as an old slug that still redirects
+func (u *blogUsecase) uniqueSlug(ctx context.Context, title string, blogID int) (string, error) {
+	base := strings.Trim(truncateRunes(slugify(title), maxSlugLength), "-")
+	if base == "" {
+		base = "blog"
+	}
+
+	slug := base
+	for n := 2; ; n++ {
+		taken, err := u.blogRepo.SlugTaken(ctx, slug, blogID)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// resolveCategory turns the category slug of a filter into the IDs of that
+// category and all of its subcategories
+func (u *blogUsecase) resolveCategory(ctx context.Context, filter *entity.BlogFilter) error {
+	if filter.Category == "" {
+		return nil
+	}
+
+	categories, err := u.categoryRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	ids, ok := categoryAndDescendants(categories, filter.Category)
+	if !ok {
+		return ErrCategoryNotFound
+	}
+	filter.CategoryIDs = ids
+	return nil
+}
+
+func (u *blogUsecase) GetRevisions(ctx context.Context, blogID int, actor *auth.Principal) ([]*entity.BlogRevision, error) {
+	if _, err := u.getOwnBlog(ctx, blogID, actor, auth.PermBlogUpdateAny); err != nil {
+		return nil, err
+	}
+	return u.blogRepo.GetRevisions(ctx, blogID)
+}
+
+// DiffRevisions compares two revisions of a blog line by line
+func (u *blogUsecase) DiffRevisions(ctx context.Context, blogID int, actor *auth.Principal, from, to int) (*entity.RevisionDiff, error) {
+	if _, err := u.getOwnBlog(ctx, blogID, actor, auth.PermBlogUpdateAny); err != nil {
+		return nil, err
+	}
+
+	fromRev, err := u.blogRepo.GetRevision(ctx, blogID, from)
+	if err != nil {
+		return nil, orNotFound(err, ErrRevisionNotFound)
+	}
+	toRev, err := u.blogRepo.GetRevision(ctx, blogID, to)
+	if err != nil {
+		return nil, orNotFound(err, ErrRevisionNotFound)
+	}
+
+	return &entity.RevisionDiff{
+		From:      from,
+		To:        to,
+		Title:     diffLines(fromRev.Title, toRev.Title),
+		Content:   diffLines(fromRev.Content, toRev.Content),
+		Thumbnail: diffLines(fromRev.Thumbnail, toRev.Thumbnail),
+	}, nil
+}
+
+// RestoreRevision brings back an old revision; the restored state is saved as a new revision
+func (u *blogUsecase) RestoreRevision(ctx context.Context, blogID int, actor *auth.Principal, revision int) (*entity.Blog, error) {
+	blog, err := u.getOwnBlog(ctx, blogID, actor, auth.PermBlogUpdateAny)
+	if err != nil {
+		return nil, err
+	}
+
+	rev, err := u.blogRepo.GetRevision(ctx, blogID, revision)
+	if err != nil {
+		return nil, orNotFound(err, ErrRevisionNotFound)
+	}
+
+	blog.Title = rev.Title
+	blog.Content = rev.Content
+	blog.Thumbnail = rev.Thumbnail
+	if err := u.Update(ctx, blog, actor); err != nil {
+		return nil, err
+	}
+	return blog, nil
+}
+
+func validCommentMode(mode string) bool {
+	switch mode {
+	case entity.CommentModeOpen, entity.CommentModeModerated, entity.CommentModeClosed:
+		return true
+	}
+	return false
+}
+
+// canView reports whether a blog may be read by the viewer: drafts, scheduled
+// and archived blogs are only visible to their author
+func canView(blog *entity.Blog, viewerID int) bool {
+	return blog.Status == entity.BlogStatusPublished || blog.UserID == viewerID
+}
+
+// getOwnBlog loads a blog and makes sure the actor wrote it, unless they
+// were granted the permission to act on every blog
+func (u *blogUsecase) getOwnBlog(ctx context.Context, id int, actor *auth.Principal, anyPermission string) (*entity.Blog, error) {
+	blog, err := u.blogRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, orNotFound(err, ErrBlogNotFound)
+	}
+	if blog.UserID != actor.UserID && !actor.Can(anyPermission) {
+		return nil, ErrNotBlogOwner
+	}
+	return blog, nil
+}
+
+// Delete removes a blog; only its author and admins may do so
+func (u *blogUsecase) Delete(ctx context.Context, id int, actor *auth.Principal) error {
+	blog, err := u.getOwnBlog(ctx, id, actor, auth.PermBlogDeleteAny)
+	if err != nil {
+		return err
+	}
+
+	// The revisions go with the blog, so their thumbnails may no longer be needed
+	revisions, err := u.blogRepo.GetRevisions(ctx, id)
+	if err != nil {
+		return err
 	}
 
 	// Proceed with the deletion
-	return u.blogRepo.Delete(id)
+	if err := u.blogRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	discarded := map[string]bool{blog.Thumbnail: true}
+	u.DiscardThumbnail(ctx, blog.Thumbnail)
+	for _, rev := range revisions {
+		if !discarded[rev.Thumbnail] {
+			discarded[rev.Thumbnail] = true
+			u.DiscardThumbnail(ctx, rev.Thumbnail)
+		}
+	}
+	return nil
+}
+
+// normalizeTags lowercases and trims tags, dropping empty and duplicate ones
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, ErrInvalidTag
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > maxTags {
+		return nil, ErrTooManyTags
+	}
+	return normalized, nil
+}
+
+// normalizePage falls back to sane defaults for missing or out of range values
+func normalizePage(page, limit *int) {
+	if *page < 1 {
+		*page = 1
+	}
+	if *limit < 1 {
+		*limit = DefaultPageLimit
+	}
+	if *limit > MaxPageLimit {
+		*limit = MaxPageLimit
+	}
+}
+
+func encodeCursor(cursor *entity.BlogCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*entity.BlogCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor entity.BlogCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
 }
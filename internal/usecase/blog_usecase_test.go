@@ -0,0 +1,276 @@
+package usecase_test
+
+import (
+	"blog-api/internal/entity"
+	"blog-api/internal/repository/memory"
+	"blog-api/internal/usecase"
+	"blog-api/pkg/auth"
+	"blog-api/pkg/storage"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// principal returns the caller of a request signed in with the given role
+func principal(userID int, role string) *auth.Principal {
+	return &auth.Principal{UserID: userID, Role: role, Scopes: auth.RolePermissions(role)}
+}
+
+func newBlogUsecase(t *testing.T, db *memory.DB) usecase.BlogUsecase {
+	t.Helper()
+	store := storage.NewLocal(t.TempDir(), "http://localhost/api/v1")
+	return usecase.NewBlogUsecase(memory.NewBlogRepository(db), memory.NewCategoryRepository(db), memory.NewMediaRepository(db), store)
+}
+
+func createBlog(t *testing.T, blogs usecase.BlogUsecase, userID int, title, status string) *entity.Blog {
+	t.Helper()
+	blog := &entity.Blog{Title: title, Content: "Content of " + title, UserID: userID, Status: status}
+	if err := blogs.Create(context.Background(), blog); err != nil {
+		t.Fatalf("Create(%q): %v", title, err)
+	}
+	return blog
+}
+
+func TestBlogCreate(t *testing.T) {
+	ctx := context.Background()
+	blogs := newBlogUsecase(t, memory.NewDB())
+
+	blog := createBlog(t, blogs, 1, "Hello, World!", "")
+	if blog.ID == 0 {
+		t.Error("blog was not given an ID")
+	}
+	if blog.Status != entity.BlogStatusDraft || blog.CommentMode != entity.CommentModeOpen {
+		t.Errorf("new blog is %s with %s comments, want a draft with open comments", blog.Status, blog.CommentMode)
+	}
+	if blog.Slug != "hello-world" {
+		t.Errorf("Slug = %q, want hello-world", blog.Slug)
+	}
+
+	// The same title gets another slug
+	second := createBlog(t, blogs, 2, "Hello, World!", entity.BlogStatusPublished)
+	if second.Slug == blog.Slug {
+		t.Errorf("two blogs share the slug %q", blog.Slug)
+	}
+	if second.PublishedAt == nil {
+		t.Error("a blog published on creation has no publication time")
+	}
+
+	err := blogs.Create(ctx, &entity.Blog{Title: "Invalid", Content: "x", UserID: 1, Status: "deleted"})
+	if !errors.Is(err, usecase.ErrInvalidStatus) {
+		t.Errorf("Create with an unknown status: error = %v, want ErrInvalidStatus", err)
+	}
+
+	err = blogs.Create(ctx, &entity.Blog{Title: "Invalid", Content: "x", UserID: 1, CommentMode: "maybe"})
+	if !errors.Is(err, usecase.ErrInvalidCommentMode) {
+		t.Errorf("Create with an unknown comment mode: error = %v, want ErrInvalidCommentMode", err)
+	}
+}
+
+func TestBlogSlugs(t *testing.T) {
+	ctx := context.Background()
+	blogs := newBlogUsecase(t, memory.NewDB())
+	long := strings.Repeat("a", 199) + " b"
+
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Same title", "same-title"},
+		{"Same title", "same-title-2"},
+		{"Same title!", "same-title-3"},
+		{"!!!", "blog"},
+		{"???", "blog-2"},
+		// Cut to 200 characters, without the dash left at the end
+		{long, strings.Repeat("a", 199)},
+		{strings.Repeat("b", 250), strings.Repeat("b", 200)},
+	}
+	for _, tt := range tests {
+		if blog := createBlog(t, blogs, 1, tt.title, ""); blog.Slug != tt.want {
+			t.Errorf("Create(%.20q): Slug = %q, want %q", tt.title, blog.Slug, tt.want)
+		}
+	}
+
+	// A renamed blog keeps its old slug as a redirect, which new blogs
+	// cannot take
+	blog := createBlog(t, blogs, 1, "Old title", entity.BlogStatusPublished)
+	if err := blogs.Update(ctx, &entity.Blog{ID: blog.ID, Title: "New title", Content: "x", UserID: 1}, principal(1, auth.RoleAuthor)); err != nil {
+		t.Fatal(err)
+	}
+	if other := createBlog(t, blogs, 1, "Old title", ""); other.Slug != "old-title-2" {
+		t.Errorf("Slug = %q, want old-title-2", other.Slug)
+	}
+	if _, canonical, err := blogs.GetVisibleBySlug(ctx, "old-title", 0); err != nil || canonical != "new-title" {
+		t.Errorf("GetVisibleBySlug(old-title) = %q, %v, want a redirect to new-title", canonical, err)
+	}
+
+	// Renaming it back takes the redirected slug back
+	if err := blogs.Update(ctx, &entity.Blog{ID: blog.ID, Title: "Old title", Content: "x", UserID: 1}, principal(1, auth.RoleAuthor)); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := blogs.GetByID(ctx, blog.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Slug != "old-title" {
+		t.Errorf("Slug after renaming back = %q, want old-title", updated.Slug)
+	}
+}
+
+func TestBlogBackfillSlugs(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewDB()
+	blogs := newBlogUsecase(t, db)
+	createBlog(t, blogs, 1, "Привет, мир", "")
+
+	// Blogs written before slugs existed, as migration 0008 leaves them
+	repo := memory.NewBlogRepository(db)
+	var old []*entity.Blog
+	for _, title := range []string{"Привет, мир", "Crème brûlée", "!!!"} {
+		blog := &entity.Blog{Title: title, Content: "x", UserID: 1, Status: entity.BlogStatusDraft, CommentMode: entity.CommentModeOpen}
+		if err := repo.Create(ctx, blog); err != nil {
+			t.Fatal(err)
+		}
+		old = append(old, blog)
+	}
+
+	n, err := blogs.BackfillSlugs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(old) {
+		t.Errorf("BackfillSlugs = %d, want %d", n, len(old))
+	}
+	for i, want := range []string{"privet-mir-2", "creme-brulee", "blog"} {
+		blog, err := repo.GetByID(ctx, old[i].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if blog.Slug != want {
+			t.Errorf("%q got the slug %q, want %q", blog.Title, blog.Slug, want)
+		}
+	}
+
+	if n, err := blogs.BackfillSlugs(ctx); err != nil || n != 0 {
+		t.Errorf("BackfillSlugs a second time = %d, %v, want 0", n, err)
+	}
+}
+
+func TestBlogVisibility(t *testing.T) {
+	ctx := context.Background()
+	blogs := newBlogUsecase(t, memory.NewDB())
+
+	draft := createBlog(t, blogs, 1, "Draft", "")
+	published := createBlog(t, blogs, 1, "Published", entity.BlogStatusPublished)
+
+	if _, err := blogs.GetVisibleByID(ctx, draft.ID, 1); err != nil {
+		t.Errorf("the author cannot see their draft: %v", err)
+	}
+	if _, err := blogs.GetVisibleByID(ctx, draft.ID, 2); !errors.Is(err, usecase.ErrBlogNotFound) {
+		t.Errorf("another user sees the draft: error = %v, want ErrBlogNotFound", err)
+	}
+	if _, err := blogs.GetVisibleByID(ctx, published.ID, 0); err != nil {
+		t.Errorf("anonymous users cannot see a published blog: %v", err)
+	}
+
+	page, err := blogs.GetAll(ctx, &entity.BlogFilter{ViewerID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || len(page.Blogs) != 1 || page.Blogs[0].ID != published.ID {
+		t.Errorf("another user lists %d blogs, want only the published one", page.Total)
+	}
+}
+
+func TestBlogOwnership(t *testing.T) {
+	ctx := context.Background()
+	blogs := newBlogUsecase(t, memory.NewDB())
+
+	author := principal(1, auth.RoleAuthor)
+	otherAuthor := principal(2, auth.RoleAuthor)
+	admin := principal(3, auth.RoleAdmin)
+
+	blog := createBlog(t, blogs, author.UserID, "Original", entity.BlogStatusPublished)
+
+	edit := func(actor *auth.Principal, title string) error {
+		return blogs.Update(ctx, &entity.Blog{ID: blog.ID, Title: title, Content: "Edited", UserID: blog.UserID}, actor)
+	}
+
+	if err := edit(otherAuthor, "Hijacked"); !errors.Is(err, usecase.ErrNotBlogOwner) {
+		t.Errorf("another author updated the blog: error = %v, want ErrNotBlogOwner", err)
+	}
+	if _, err := blogs.ChangeStatus(ctx, blog.ID, otherAuthor, entity.BlogStatusArchived); !errors.Is(err, usecase.ErrNotBlogOwner) {
+		t.Errorf("another author archived the blog: error = %v, want ErrNotBlogOwner", err)
+	}
+	if err := blogs.Delete(ctx, blog.ID, otherAuthor); !errors.Is(err, usecase.ErrNotBlogOwner) {
+		t.Errorf("another author deleted the blog: error = %v, want ErrNotBlogOwner", err)
+	}
+
+	if err := edit(author, "Edited by the author"); err != nil {
+		t.Errorf("the author cannot update the blog: %v", err)
+	}
+	if err := edit(admin, "Edited by an admin"); err != nil {
+		t.Errorf("an admin cannot update the blog: %v", err)
+	}
+
+	updated, err := blogs.GetByID(ctx, blog.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Title != "Edited by an admin" || updated.Slug != "edited-by-an-admin" {
+		t.Errorf("blog is %q at %q after the updates", updated.Title, updated.Slug)
+	}
+
+	// The old slug still leads to the blog
+	_, canonical, err := blogs.GetVisibleBySlug(ctx, "original", 0)
+	if err != nil || canonical != updated.Slug {
+		t.Errorf("GetVisibleBySlug(original) = %q, %v, want a redirect to %q", canonical, err, updated.Slug)
+	}
+
+	revisions, err := blogs.GetRevisions(ctx, blog.ID, author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 3 {
+		t.Errorf("blog has %d revisions, want 3", len(revisions))
+	}
+
+	if err := blogs.Delete(ctx, blog.ID, admin); err != nil {
+		t.Errorf("an admin cannot delete the blog: %v", err)
+	}
+	if _, err := blogs.GetByID(ctx, blog.ID); !errors.Is(err, usecase.ErrBlogNotFound) {
+		t.Errorf("GetByID after Delete: error = %v, want ErrBlogNotFound", err)
+	}
+	if err := blogs.Delete(ctx, blog.ID, admin); !errors.Is(err, usecase.ErrBlogNotFound) {
+		t.Errorf("deleting the blog twice: error = %v, want ErrBlogNotFound", err)
+	}
+}
+
+func TestBlogStatusTransitions(t *testing.T) {
+	ctx := context.Background()
+	blogs := newBlogUsecase(t, memory.NewDB())
+	author := principal(1, auth.RoleAuthor)
+
+	blog := createBlog(t, blogs, author.UserID, "Transitions", "")
+
+	published, err := blogs.ChangeStatus(ctx, blog.ID, author, entity.BlogStatusPublished)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if published.PublishedAt == nil {
+		t.Fatal("published blog has no publication time")
+	}
+
+	archived, err := blogs.ChangeStatus(ctx, blog.ID, author, entity.BlogStatusArchived)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archived.Status != entity.BlogStatusArchived {
+		t.Errorf("Status = %s, want archived", archived.Status)
+	}
+
+	// Archived blogs go back to drafts before they are published again
+	if _, err := blogs.ChangeStatus(ctx, blog.ID, author, entity.BlogStatusPublished); !errors.Is(err, usecase.ErrStatusTransition) {
+		t.Errorf("publishing an archived blog: error = %v, want ErrStatusTransition", err)
+	}
+}
@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"blog-api/internal/entity"
+	"context"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrCategoryNotFound is returned when a category does not exist
+	ErrCategoryNotFound = notFoundError("category not found")
+
+	// ErrCategoryExists is returned when another category already uses the slug
+	ErrCategoryExists = conflictError("a category with this slug already exists")
+
+	// ErrCategoryHasChildren is returned when deleting a category that still has subcategories
+	ErrCategoryHasChildren = conflictError("category still has subcategories")
+
+	// ErrInvalidCategory is returned for empty names and parents that would create a cycle
+	ErrInvalidCategory = validationError("", "invalid category")
+)
+
+type CategoryUsecase interface {
+	Create(ctx context.Context, category *entity.Category) error
+	GetTree(ctx context.Context) ([]*entity.Category, error)
+	Update(ctx context.Context, category *entity.Category) error
+	Delete(ctx context.Context, id int) error
+}
+
+type categoryUsecase struct {
+	categoryRepo CategoryRepository
+}
+
+func NewCategoryUsecase(categoryRepo CategoryRepository) CategoryUsecase {
+	return &categoryUsecase{categoryRepo: categoryRepo}
+}
+
+func (u *categoryUsecase) Create(ctx context.Context, category *entity.Category) error {
+	if err := u.validate(ctx, category); err != nil {
+		return err
+	}
+	return u.categoryRepo.Create(ctx, category)
+}
+
+// GetTree returns the root categories with their subcategories nested inside
+func (u *categoryUsecase) GetTree(ctx context.Context) ([]*entity.Category, error) {
+	categories, err := u.categoryRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return buildCategoryTree(categories), nil
+}
+
+func (u *categoryUsecase) Update(ctx context.Context, category *entity.Category) error {
+	if _, err := u.categoryRepo.GetByID(ctx, category.ID); err != nil {
+		return orNotFound(err, ErrCategoryNotFound)
+	}
+	if err := u.validate(ctx, category); err != nil {
+		return err
+	}
+	return u.categoryRepo.Update(ctx, category)
+}
+
+func (u *categoryUsecase) Delete(ctx context.Context, id int) error {
+	categories, err := u.categoryRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, c := range categories {
+		if c.ID == id {
+			found = true
+		}
+		if c.ParentID != nil && *c.ParentID == id {
+			return ErrCategoryHasChildren
+		}
+	}
+	if !found {
+		return ErrCategoryNotFound
+	}
+
+	// Blogs in the category become uncategorized
+	return u.categoryRepo.Delete(ctx, id)
+}
+
+// validate fills in the slug and checks it is unique and the parent exists
+// without turning the tree into a cycle
+func (u *categoryUsecase) validate(ctx context.Context, category *entity.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	if category.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCategory)
+	}
+	category.Slug = slugify(category.Slug)
+	if category.Slug == "" {
+		category.Slug = slugify(category.Name)
+	}
+	if category.Slug == "" {
+		return fmt.Errorf("%w: name must contain letters or digits", ErrInvalidCategory)
+	}
+
+	categories, err := u.categoryRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[int]*entity.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+		if c.Slug == category.Slug && c.ID != category.ID {
+			return ErrCategoryExists
+		}
+	}
+
+	if category.ParentID == nil {
+		return nil
+	}
+	if _, ok := byID[*category.ParentID]; !ok {
+		return invalid("parent_id", ErrCategoryNotFound)
+	}
+
+	// Walk up from the new parent; reaching the category itself means a cycle
+	for id := category.ParentID; id != nil; id = byID[*id].ParentID {
+		if *id == category.ID {
+			return fmt.Errorf("%w: a category cannot be nested inside itself", ErrInvalidCategory)
+		}
+	}
+	return nil
+}
+
+// buildCategoryTree nests categories under their parents and adds the post
+// counts of subcategories to their ancestors
+func buildCategoryTree(categories []*entity.Category) []*entity.Category {
+	byID := make(map[int]*entity.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+
+	roots := []*entity.Category{}
+	for _, c := range categories {
+		if c.ParentID != nil {
+			if parent, ok := byID[*c.ParentID]; ok {
+				parent.Children = append(parent.Children, c)
+				continue
+			}
+		}
+		roots = append(roots, c)
+	}
+
+	var sum func(c *entity.Category) int
+	sum = func(c *entity.Category) int {
+		for _, child := range c.Children {
+			c.PostCount += sum(child)
+		}
+		return c.PostCount
+	}
+	for _, root := range roots {
+		sum(root)
+	}
+
+	return roots
+}
+
+// categoryAndDescendants returns the ID of the category with the given slug
+// followed by the IDs of all categories below it
+func categoryAndDescendants(categories []*entity.Category, slug string) ([]int, bool) {
+	var root *entity.Category
+	children := make(map[int][]int)
+	for _, c := range categories {
+		if c.Slug == slug {
+			root = c
+		}
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+	if root == nil {
+		return nil, false
+	}
+
+	ids := []int{root.ID}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids, true
+}
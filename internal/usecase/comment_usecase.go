@@ -0,0 +1,301 @@
+package usecase
+
+import (
+	"blog-api/internal/entity"
+	"blog-api/pkg/auth"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrCommentNotFound is returned when a comment does not exist or its blog is hidden from the caller
+	ErrCommentNotFound = notFoundError("comment not found")
+
+	// ErrNotCommentOwner is returned when a user edits or deletes a comment they may not touch
+	ErrNotCommentOwner = forbiddenError("only the author of the comment can do this")
+
+	// ErrEmptyComment is returned for comments without content
+	ErrEmptyComment = validationError("content", "comment content is required")
+
+	// ErrInvalidParent is returned when replying to a comment that is missing or on another blog
+	ErrInvalidParent = validationError("parent_id", "parent comment not found on this blog")
+
+	// ErrCommentsClosed is returned when commenting on a blog whose comments are closed
+	ErrCommentsClosed = forbiddenError("comments are closed on this blog")
+
+	// ErrNotBlogAuthor is returned when someone other than the blog's author moderates its comments
+	ErrNotBlogAuthor = forbiddenError("only the author of the blog can moderate its comments")
+
+	// ErrMaxDepth is returned when a reply would be nested deeper than MaxCommentDepth
+	ErrMaxDepth = validationError("parent_id", fmt.Sprintf("replies cannot be nested more than %d levels deep", MaxCommentDepth))
+)
+
+// MaxCommentDepth is the deepest a reply may be nested; top-level comments have depth 0
+const MaxCommentDepth = 5
+
+type CommentUsecase interface {
+	Create(ctx context.Context, comment *entity.Comment) error
+	GetByBlog(ctx context.Context, filter *entity.CommentFilter) (*entity.CommentPage, error)
+	GetByID(ctx context.Context, id, viewerID int) (*entity.Comment, error)
+	Update(ctx context.Context, id, userID int, content string) (*entity.Comment, error)
+	Delete(ctx context.Context, id int, actor *auth.Principal) error
+	GetPending(ctx context.Context, actor *auth.Principal, filter *entity.ModerationFilter) (*entity.CommentPage, error)
+	Moderate(ctx context.Context, id int, actor *auth.Principal, status string) (*entity.Comment, error)
+}
+
+type commentUsecase struct {
+	commentRepo CommentRepository
+	blogRepo    BlogRepository
+}
+
+func NewCommentUsecase(commentRepo CommentRepository, blogRepo BlogRepository) CommentUsecase {
+	return &commentUsecase{
+		commentRepo: commentRepo,
+		blogRepo:    blogRepo,
+	}
+}
+
+func (u *commentUsecase) Create(ctx context.Context, comment *entity.Comment) error {
+	comment.Content = strings.TrimSpace(comment.Content)
+	if comment.Content == "" {
+		return ErrEmptyComment
+	}
+
+	// Check that the blog exists and can be read by the commenter
+	blog, err := u.visibleBlog(ctx, comment.BlogID, comment.UserID)
+	if err != nil {
+		return err
+	}
+
+	status, err := newCommentStatus(blog, comment.UserID)
+	if err != nil {
+		return err
+	}
+	comment.Status = status
+
+	// Replies join the thread of their parent, one level deeper
+	comment.RootID = nil
+	comment.Depth = 0
+	if comment.ParentID != nil {
+		parent, err := u.commentRepo.GetByID(ctx, *comment.ParentID)
+		if err != nil {
+			return orNotFound(err, ErrInvalidParent)
+		}
+		if parent.BlogID != comment.BlogID || parent.Status != entity.CommentStatusApproved {
+			return ErrInvalidParent
+		}
+		if parent.Depth+1 > MaxCommentDepth {
+			return ErrMaxDepth
+		}
+
+		comment.Depth = parent.Depth + 1
+		comment.RootID = parent.RootID
+		if comment.RootID == nil {
+			comment.RootID = &parent.ID
+		}
+	}
+
+	return u.commentRepo.Create(ctx, comment)
+}
+
+func (u *commentUsecase) GetByBlog(ctx context.Context, filter *entity.CommentFilter) (*entity.CommentPage, error) {
+	normalizePage(&filter.Page, &filter.Limit)
+
+	if _, err := u.visibleBlog(ctx, filter.BlogID, filter.ViewerID); err != nil {
+		return nil, err
+	}
+
+	page, err := u.commentRepo.GetByBlog(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load the replies of every thread on the page at once
+	rootIDs := make([]int, len(page.Comments))
+	for i, comment := range page.Comments {
+		rootIDs[i] = comment.ID
+	}
+	replies, err := u.commentRepo.GetReplies(ctx, rootIDs, filter.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	buildCommentTree(page.Comments, replies)
+	if filter.Flat {
+		page.Comments = flattenCommentTree(page.Comments)
+	}
+	return page, nil
+}
+
+func (u *commentUsecase) GetByID(ctx context.Context, id, viewerID int) (*entity.Comment, error) {
+	comment, err := u.commentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, orNotFound(err, ErrCommentNotFound)
+	}
+
+	// Comments on hidden blogs are hidden as well
+	blog, err := u.visibleBlog(ctx, comment.BlogID, viewerID)
+	if errors.Is(err, ErrBlogNotFound) {
+		return nil, ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Unapproved comments are only shown to their author and the blog's author
+	if comment.Status != entity.CommentStatusApproved && comment.UserID != viewerID && blog.UserID != viewerID {
+		return nil, ErrCommentNotFound
+	}
+	return comment, nil
+}
+
+// Update changes the content of a comment; only its author may do so
+func (u *commentUsecase) Update(ctx context.Context, id, userID int, content string) (*entity.Comment, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, ErrEmptyComment
+	}
+
+	comment, err := u.commentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, orNotFound(err, ErrCommentNotFound)
+	}
+	if comment.UserID != userID {
+		return nil, ErrNotCommentOwner
+	}
+
+	// Edited comments on moderated blogs have to be approved again
+	blog, err := u.blogRepo.GetByID(ctx, comment.BlogID)
+	if err != nil {
+		return nil, orNotFound(err, ErrCommentNotFound)
+	}
+	if comment.Status == entity.CommentStatusApproved {
+		status, err := newCommentStatus(blog, userID)
+		if err != nil {
+			return nil, err
+		}
+		comment.Status = status
+	}
+
+	comment.Content = content
+	if err := u.commentRepo.Update(ctx, comment); err != nil {
+		return nil, err
+	}
+	return u.commentRepo.GetByID(ctx, id)
+}
+
+// Delete removes a comment; its author, the author of the blog and admins
+// may do so
+func (u *commentUsecase) Delete(ctx context.Context, id int, actor *auth.Principal) error {
+	comment, err := u.commentRepo.GetByID(ctx, id)
+	if err != nil {
+		return orNotFound(err, ErrCommentNotFound)
+	}
+
+	if comment.UserID != actor.UserID && !actor.Can(auth.PermCommentDeleteAny) {
+		blog, err := u.blogRepo.GetByID(ctx, comment.BlogID)
+		if err != nil {
+			return orNotFound(err, ErrCommentNotFound)
+		}
+		if blog.UserID != actor.UserID {
+			return ErrNotCommentOwner
+		}
+	}
+
+	return u.commentRepo.Delete(ctx, id)
+}
+
+// GetPending lists the comments waiting for approval on the actor's blogs,
+// or on every blog for actors allowed to moderate them all
+func (u *commentUsecase) GetPending(ctx context.Context, actor *auth.Principal, filter *entity.ModerationFilter) (*entity.CommentPage, error) {
+	filter.AuthorID = actor.UserID
+	if actor.Can(auth.PermCommentModerateAny) {
+		filter.AuthorID = 0
+	}
+	normalizePage(&filter.Page, &filter.Limit)
+	return u.commentRepo.GetPending(ctx, filter)
+}
+
+// Moderate approves or rejects a comment; only the blog's author and admins
+// may do so
+func (u *commentUsecase) Moderate(ctx context.Context, id int, actor *auth.Principal, status string) (*entity.Comment, error) {
+	comment, err := u.commentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, orNotFound(err, ErrCommentNotFound)
+	}
+
+	blog, err := u.blogRepo.GetByID(ctx, comment.BlogID)
+	if err != nil {
+		return nil, orNotFound(err, ErrCommentNotFound)
+	}
+	if blog.UserID != actor.UserID && !actor.Can(auth.PermCommentModerateAny) {
+		return nil, ErrNotBlogAuthor
+	}
+
+	comment.Status = status
+	if err := u.commentRepo.UpdateStatus(ctx, comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// newCommentStatus decides the status of a new or edited comment from the
+// blog's comment mode. The blog's author never needs approval.
+func newCommentStatus(blog *entity.Blog, userID int) (string, error) {
+	switch {
+	case blog.CommentMode == entity.CommentModeClosed:
+		return "", ErrCommentsClosed
+	case blog.CommentMode == entity.CommentModeModerated && blog.UserID != userID:
+		return entity.CommentStatusPending, nil
+	default:
+		return entity.CommentStatusApproved, nil
+	}
+}
+
+// visibleBlog loads a blog the viewer is allowed to read
+func (u *commentUsecase) visibleBlog(ctx context.Context, id, viewerID int) (*entity.Blog, error) {
+	blog, err := u.blogRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, orNotFound(err, ErrBlogNotFound)
+	}
+	if !canView(blog, viewerID) {
+		return nil, ErrBlogNotFound
+	}
+	return blog, nil
+}
+
+// buildCommentTree attaches every reply to its parent
+func buildCommentTree(roots, replies []*entity.Comment) {
+	byID := make(map[int]*entity.Comment, len(roots)+len(replies))
+	for _, comment := range roots {
+		byID[comment.ID] = comment
+	}
+	for _, reply := range replies {
+		byID[reply.ID] = reply
+	}
+
+	for _, reply := range replies {
+		if parent, ok := byID[*reply.ParentID]; ok {
+			parent.Replies = append(parent.Replies, reply)
+		}
+	}
+}
+
+// flattenCommentTree lists the comments in reading order, every reply
+// directly after its parent; Depth tells how far each one is indented
+func flattenCommentTree(roots []*entity.Comment) []*entity.Comment {
+	flat := []*entity.Comment{}
+	var walk func(comments []*entity.Comment)
+	walk = func(comments []*entity.Comment) {
+		for _, comment := range comments {
+			replies := comment.Replies
+			comment.Replies = nil
+			flat = append(flat, comment)
+			walk(replies)
+		}
+	}
+	walk(roots)
+	return flat
+}
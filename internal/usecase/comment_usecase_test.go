@@ -0,0 +1,159 @@
+package usecase_test
+
+import (
+	"blog-api/internal/entity"
+	"blog-api/internal/repository/memory"
+	"blog-api/internal/usecase"
+	"blog-api/pkg/auth"
+	"context"
+	"errors"
+	"testing"
+)
+
+func newCommentUsecase(t *testing.T, db *memory.DB) usecase.CommentUsecase {
+	t.Helper()
+	return usecase.NewCommentUsecase(memory.NewCommentRepository(db), memory.NewBlogRepository(db))
+}
+
+func moderatedBlog(t *testing.T, blogs usecase.BlogUsecase, userID int, title string) *entity.Blog {
+	t.Helper()
+	blog := &entity.Blog{Title: title, Content: "x", UserID: userID, Status: entity.BlogStatusPublished, CommentMode: entity.CommentModeModerated}
+	if err := blogs.Create(context.Background(), blog); err != nil {
+		t.Fatal(err)
+	}
+	return blog
+}
+
+func TestCommentModeration(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewDB()
+	blogs := newBlogUsecase(t, db)
+	comments := newCommentUsecase(t, db)
+
+	author := principal(1, auth.RoleAuthor)
+	otherAuthor := principal(2, auth.RoleAuthor)
+	reader := principal(3, auth.RoleUser)
+
+	blog := moderatedBlog(t, blogs, author.UserID, "Moderated")
+
+	comment := &entity.Comment{BlogID: blog.ID, UserID: reader.UserID, Content: "  First!  "}
+	if err := comments.Create(ctx, comment); err != nil {
+		t.Fatal(err)
+	}
+	if comment.Status != entity.CommentStatusPending || comment.Content != "First!" {
+		t.Errorf("comment is %s with %q, want pending with trimmed content", comment.Status, comment.Content)
+	}
+
+	// The blog's author never needs approval
+	own := &entity.Comment{BlogID: blog.ID, UserID: author.UserID, Content: "Thanks for reading"}
+	if err := comments.Create(ctx, own); err != nil {
+		t.Fatal(err)
+	}
+	if own.Status != entity.CommentStatusApproved {
+		t.Errorf("the author's comment is %s, want approved", own.Status)
+	}
+
+	// Pending comments are hidden from everyone but their author and the blog's author
+	if _, err := comments.GetByID(ctx, comment.ID, otherAuthor.UserID); !errors.Is(err, usecase.ErrCommentNotFound) {
+		t.Errorf("another user sees the pending comment: error = %v, want ErrCommentNotFound", err)
+	}
+	for _, viewer := range []int{reader.UserID, author.UserID} {
+		if _, err := comments.GetByID(ctx, comment.ID, viewer); err != nil {
+			t.Errorf("user %d cannot see the pending comment: %v", viewer, err)
+		}
+	}
+
+	if _, err := comments.Moderate(ctx, comment.ID, otherAuthor, entity.CommentStatusApproved); !errors.Is(err, usecase.ErrNotBlogAuthor) {
+		t.Errorf("another author moderated the comment: error = %v, want ErrNotBlogAuthor", err)
+	}
+
+	approved, err := comments.Moderate(ctx, comment.ID, author, entity.CommentStatusApproved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if approved.Status != entity.CommentStatusApproved {
+		t.Errorf("Status = %s, want approved", approved.Status)
+	}
+	if _, err := comments.GetByID(ctx, comment.ID, 0); err != nil {
+		t.Errorf("the approved comment is hidden: %v", err)
+	}
+
+	// Editing an approved comment sends it back for approval
+	edited, err := comments.Update(ctx, comment.ID, reader.UserID, "First, edited")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edited.Status != entity.CommentStatusPending {
+		t.Errorf("edited comment is %s, want pending", edited.Status)
+	}
+	if _, err := comments.Update(ctx, comment.ID, otherAuthor.UserID, "Not mine"); !errors.Is(err, usecase.ErrNotCommentOwner) {
+		t.Errorf("another user edited the comment: error = %v, want ErrNotCommentOwner", err)
+	}
+}
+
+func TestCommentPendingQueue(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewDB()
+	blogs := newBlogUsecase(t, db)
+	comments := newCommentUsecase(t, db)
+
+	author := principal(1, auth.RoleAuthor)
+	otherAuthor := principal(2, auth.RoleAuthor)
+	admin := principal(3, auth.RoleAdmin)
+	reader := principal(4, auth.RoleUser)
+
+	for _, blog := range []*entity.Blog{
+		moderatedBlog(t, blogs, author.UserID, "First"),
+		moderatedBlog(t, blogs, otherAuthor.UserID, "Second"),
+	} {
+		if err := comments.Create(ctx, &entity.Comment{BlogID: blog.ID, UserID: reader.UserID, Content: "Pending"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		actor *auth.Principal
+		want  int
+	}{
+		{"author", author, 1},
+		{"other author", otherAuthor, 1},
+		{"admin", admin, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := comments.GetPending(ctx, tt.actor, &entity.ModerationFilter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Total != tt.want || len(page.Comments) != tt.want {
+				t.Errorf("%d pending comments, want %d", page.Total, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommentsClosed(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewDB()
+	blogs := newBlogUsecase(t, db)
+	comments := newCommentUsecase(t, db)
+
+	author := principal(1, auth.RoleAuthor)
+	blog := createBlog(t, blogs, author.UserID, "Closed", entity.BlogStatusPublished)
+	if _, err := blogs.SetCommentMode(ctx, blog.ID, author, entity.CommentModeClosed); err != nil {
+		t.Fatal(err)
+	}
+
+	err := comments.Create(ctx, &entity.Comment{BlogID: blog.ID, UserID: 2, Content: "Hello"})
+	if !errors.Is(err, usecase.ErrCommentsClosed) {
+		t.Errorf("Create on a closed blog: error = %v, want ErrCommentsClosed", err)
+	}
+
+	// Drafts cannot be commented on by other users
+	draft := createBlog(t, blogs, author.UserID, "Draft", "")
+	err = comments.Create(ctx, &entity.Comment{BlogID: draft.ID, UserID: 2, Content: "Hello"})
+	if !errors.Is(err, usecase.ErrBlogNotFound) {
+		t.Errorf("Create on another user's draft: error = %v, want ErrBlogNotFound", err)
+	}
+}
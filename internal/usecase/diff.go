@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"strings"
+
+	"blog-api/internal/entity"
+)
+
+// diffLines computes a line-based diff between a and b using the longest
+// common subsequence of their lines
+func diffLines(a, b string) []entity.DiffLine {
+	x := strings.Split(a, "\n")
+	y := strings.Split(b, "\n")
+
+	// lcs[i][j] is the LCS length of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	diff := []entity.DiffLine{}
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			diff = append(diff, entity.DiffLine{Op: entity.DiffEqual, Text: x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, entity.DiffLine{Op: entity.DiffDelete, Text: x[i]})
+			i++
+		default:
+			diff = append(diff, entity.DiffLine{Op: entity.DiffInsert, Text: y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		diff = append(diff, entity.DiffLine{Op: entity.DiffDelete, Text: x[i]})
+	}
+	for ; j < len(y); j++ {
+		diff = append(diff, entity.DiffLine{Op: entity.DiffInsert, Text: y[j]})
+	}
+
+	return diff
+}
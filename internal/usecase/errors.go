@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"blog-api/internal/repository"
+	"errors"
+)
+
+// Kinds of domain errors. Every error the usecases return on purpose is an
+// *Error of one of these kinds, which the delivery layer translates to a
+// response without knowing the individual errors.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrForbidden    = errors.New("forbidden")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// Error is a domain error. Field names the input a validation error is
+// about, if any.
+type Error struct {
+	Kind    error
+	Field   string
+	Message string
+	cause   error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is makes errors.Is match an error against its kind
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+func notFoundError(message string) *Error {
+	return &Error{Kind: ErrNotFound, Message: message}
+}
+
+func conflictError(message string) *Error {
+	return &Error{Kind: ErrConflict, Message: message}
+}
+
+func forbiddenError(message string) *Error {
+	return &Error{Kind: ErrForbidden, Message: message}
+}
+
+func validationError(field, message string) *Error {
+	return &Error{Kind: ErrValidation, Field: field, Message: message}
+}
+
+// invalid reports err as a validation error of the given field, e.g. a
+// category that does not exist given as a blog's category_id. The original
+// error can still be matched with errors.Is.
+func invalid(field string, err error) error {
+	return &Error{Kind: ErrValidation, Field: field, Message: err.Error(), cause: err}
+}
+
+// orNotFound replaces a repository's not found error with a domain error
+// and passes any other error on
+func orNotFound(err error, domainErr error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return domainErr
+	}
+	return err
+}
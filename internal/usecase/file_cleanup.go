@@ -0,0 +1,35 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// fileCleanupDelay is how long a stored file is kept after it was uploaded
+// or stopped being used. Files are content addressed, so an identical upload
+// may reuse a file that is about to be removed; uploads are linked to a blog
+// or media item well within the delay, and the cleanup checks again.
+const fileCleanupDelay = time.Hour
+
+// cleanupBatchSize is how many files one cleanup run looks at
+const cleanupBatchSize = 100
+
+// holdFile keeps a stored file for fileCleanupDelay, after which it is
+// removed unless something uses it by then
+func holdFile(repo MediaRepository) func(ctx context.Context, path string) error {
+	return func(ctx context.Context, path string) error {
+		return repo.ScheduleCleanup(ctx, path, time.Now().Add(fileCleanupDelay))
+	}
+}
+
+// discardFile schedules the removal of a stored file that may no longer be
+// needed. It is scheduled even when ctx has been cancelled.
+func discardFile(ctx context.Context, repo MediaRepository, path string) {
+	if path == "" {
+		return
+	}
+	if err := holdFile(repo)(context.WithoutCancel(ctx), path); err != nil {
+		log.Printf("Error scheduling the removal of %s: %v", path, err)
+	}
+}
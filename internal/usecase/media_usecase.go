@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"blog-api/internal/entity"
+	"blog-api/pkg/imaging"
+	"blog-api/pkg/storage"
+	"blog-api/pkg/upload"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	// ErrMediaNotFound is returned when a media item does not exist or belongs to another user
+	ErrMediaNotFound = notFoundError("media not found")
+
+	// ErrMediaInUse is returned when deleting a media item one of the user's blogs still shows
+	ErrMediaInUse = conflictError("media is used by one of your blogs")
+
+	// ErrInvalidMedia is returned when an upload is not an acceptable image
+	ErrInvalidMedia = validationError("file", "invalid media")
+)
+
+type MediaUsecase interface {
+	Upload(ctx context.Context, userID int, src io.Reader) (*entity.Media, error)
+	GetByUser(ctx context.Context, filter *entity.MediaFilter) (*entity.MediaPage, error)
+	GetByID(ctx context.Context, id, userID int) (*entity.Media, error)
+	Delete(ctx context.Context, id, userID int) error
+	CleanUpFiles(ctx context.Context, now time.Time) (int, error)
+}
+
+type mediaUsecase struct {
+	mediaRepo MediaRepository
+	storage   storage.Storage
+}
+
+func NewMediaUsecase(mediaRepo MediaRepository, store storage.Storage) MediaUsecase {
+	return &mediaUsecase{
+		mediaRepo: mediaRepo,
+		storage:   store,
+	}
+}
+
+// Upload validates and stores an image and adds it to the user's library
+func (u *mediaUsecase) Upload(ctx context.Context, userID int, src io.Reader) (*entity.Media, error) {
+	image, err := upload.SaveImage(ctx, u.storage, src, holdFile(u.mediaRepo))
+	if err != nil {
+		if isImageError(err) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMedia, err)
+		}
+		return nil, err
+	}
+
+	media := &entity.Media{
+		UserID:      userID,
+		Path:        image.Key,
+		ContentType: image.ContentType,
+		Width:       image.Width,
+		Height:      image.Height,
+		Size:        image.Size,
+	}
+	if err := u.mediaRepo.Create(ctx, media); err != nil {
+		discardFile(ctx, u.mediaRepo, image.Key)
+		return nil, err
+	}
+	return media, nil
+}
+
+func (u *mediaUsecase) GetByUser(ctx context.Context, filter *entity.MediaFilter) (*entity.MediaPage, error) {
+	normalizePage(&filter.Page, &filter.Limit)
+	return u.mediaRepo.GetByUser(ctx, filter)
+}
+
+func (u *mediaUsecase) GetByID(ctx context.Context, id, userID int) (*entity.Media, error) {
+	media, err := u.mediaRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, orNotFound(err, ErrMediaNotFound)
+	}
+	if media.UserID != userID {
+		return nil, ErrMediaNotFound
+	}
+	return media, nil
+}
+
+// Delete removes an item from the user's library. Items still shown by one
+// of the user's blogs, as thumbnail or in the content, cannot be deleted.
+func (u *mediaUsecase) Delete(ctx context.Context, id, userID int) error {
+	media, err := u.GetByID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	used, err := u.mediaRepo.UsedByUser(ctx, media.Path, userID)
+	if err != nil {
+		return err
+	}
+	if used {
+		return ErrMediaInUse
+	}
+
+	if err := u.mediaRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	discardFile(ctx, u.mediaRepo, media.Path)
+	return nil
+}
+
+// CleanUpFiles removes the stored files whose cleanup is due and that
+// nothing uses anymore, and returns how many it removed
+func (u *mediaUsecase) CleanUpFiles(ctx context.Context, now time.Time) (int, error) {
+	paths, err := u.mediaRepo.DueCleanups(ctx, now, cleanupBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, path := range paths {
+		ok, err := u.mediaRepo.CleanUp(ctx, path, now, func(ctx context.Context) error {
+			return upload.Remove(ctx, u.storage, path)
+		})
+		if err != nil {
+			return removed, err
+		}
+		if ok {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// isImageError reports whether an upload was rejected for not being an
+// acceptable image, as opposed to failing to be stored
+func isImageError(err error) bool {
+	return errors.Is(err, upload.ErrTooLarge) ||
+		errors.Is(err, imaging.ErrUnsupportedFormat) ||
+		errors.Is(err, imaging.ErrTooManyPixels) ||
+		errors.Is(err, imaging.ErrCorrupt)
+}
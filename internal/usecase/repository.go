@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"blog-api/internal/entity"
+	"context"
+	"time"
+)
+
+// The repositories the usecases depend on. Lookups of missing records fail
+// with repository.ErrNotFound. They are implemented on MySQL by
+// repository/mysql and in memory by repository/memory.
+
+type BlogRepository interface {
+	Create(ctx context.Context, blog *entity.Blog) error
+	GetAll(ctx context.Context, filter *entity.BlogFilter) (*entity.BlogPage, error)
+	Search(ctx context.Context, query string, filter *entity.BlogFilter) (*entity.BlogSearchPage, error)
+	GetByID(ctx context.Context, id int) (*entity.Blog, error)
+	GetBySlug(ctx context.Context, slug string) (*entity.Blog, error)
+	// GetRedirectSlug returns the current slug of the blog an outdated slug
+	// belonged to, or "" when the slug never existed
+	GetRedirectSlug(ctx context.Context, oldSlug string) (string, error)
+	SlugTaken(ctx context.Context, slug string, blogID int) (bool, error)
+	// GetWithoutSlug returns the blogs written before slugs existed, oldest
+	// first, with only their ID and title
+	GetWithoutSlug(ctx context.Context) ([]*entity.Blog, error)
+	// SetSlug gives a blog without a slug its slug; it does nothing when the
+	// blog got one in the meantime
+	SetSlug(ctx context.Context, blog *entity.Blog) error
+	Update(ctx context.Context, blog *entity.Blog) error
+	UpdateStatus(ctx context.Context, blog *entity.Blog) error
+	UpdateCommentMode(ctx context.Context, blog *entity.Blog) error
+	PublishDue(ctx context.Context, now time.Time) (int64, error)
+	Delete(ctx context.Context, id int) error
+	GetRevisions(ctx context.Context, blogID int) ([]*entity.BlogRevision, error)
+	GetRevision(ctx context.Context, blogID, revision int) (*entity.BlogRevision, error)
+	GetTags(ctx context.Context) ([]*entity.Tag, error)
+}
+
+type CategoryRepository interface {
+	Create(ctx context.Context, category *entity.Category) error
+	GetAll(ctx context.Context) ([]*entity.Category, error)
+	GetByID(ctx context.Context, id int) (*entity.Category, error)
+	Update(ctx context.Context, category *entity.Category) error
+	Delete(ctx context.Context, id int) error
+}
+
+type CommentRepository interface {
+	Create(ctx context.Context, comment *entity.Comment) error
+	GetByBlog(ctx context.Context, filter *entity.CommentFilter) (*entity.CommentPage, error)
+	GetReplies(ctx context.Context, rootIDs []int, viewerID int) ([]*entity.Comment, error)
+	GetPending(ctx context.Context, filter *entity.ModerationFilter) (*entity.CommentPage, error)
+	GetByID(ctx context.Context, id int) (*entity.Comment, error)
+	Update(ctx context.Context, comment *entity.Comment) error
+	UpdateStatus(ctx context.Context, comment *entity.Comment) error
+	Delete(ctx context.Context, id int) error
+}
+
+type MediaRepository interface {
+	Create(ctx context.Context, media *entity.Media) error
+	GetByUser(ctx context.Context, filter *entity.MediaFilter) (*entity.MediaPage, error)
+	GetByID(ctx context.Context, id int) (*entity.Media, error)
+	UsedByUser(ctx context.Context, path string, userID int) (bool, error)
+	FileInUse(ctx context.Context, path string) (bool, error)
+	Delete(ctx context.Context, id int) error
+	ScheduleCleanup(ctx context.Context, path string, dueAt time.Time) error
+	DueCleanups(ctx context.Context, now time.Time, limit int) ([]string, error)
+	CleanUp(ctx context.Context, path string, now time.Time, remove func(ctx context.Context) error) (bool, error)
+}
+
+type UserRepository interface {
+	Create(ctx context.Context, user *entity.User) error
+	GetByID(ctx context.Context, id int) (*entity.User, error)
+	GetByUsername(ctx context.Context, username string) (*entity.User, error)
+	GetByUsernameOrEmail(ctx context.Context, username, email string) (*entity.User, error)
+	UpdateRole(ctx context.Context, id int, role string) error
+}
+
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *entity.RefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (*entity.RefreshToken, error)
+	// UseRefreshToken marks a refresh token as used and reports whether it
+	// was unused and unrevoked until then
+	UseRefreshToken(ctx context.Context, id int) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeUserFamilies(ctx context.Context, userID int) error
+	RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
+type SigningKeyRepository interface {
+	// LockSigningKeys makes replicas take turns at rotating the keys; the
+	// lock is held until unlock is called
+	LockSigningKeys(ctx context.Context) (unlock func(), err error)
+	ListSigningKeys(ctx context.Context) ([]*entity.SigningKey, error)
+	// RotateSigningKey stores a new key and retires every other key without
+	// a NotAfter at retireAt
+	RotateSigningKey(ctx context.Context, key *entity.SigningKey, retireAt time.Time) error
+	// DeleteExpiredSigningKeys removes the keys whose NotAfter has passed
+	DeleteExpiredSigningKeys(ctx context.Context, now time.Time) error
+}
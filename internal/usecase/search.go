@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const snippetRadius = 80
+
+// searchTerms splits a search query into the words that should be highlighted
+func searchTerms(query string) []string {
+	var terms []string
+	for _, field := range strings.Fields(query) {
+		term := strings.Trim(field, `+-~<>()*"'`)
+		if term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// highlightSnippet cuts a short, HTML escaped excerpt of content around the
+// first matching term and wraps every match in <mark> tags
+func highlightSnippet(content string, terms []string) string {
+	if len(terms) == 0 {
+		return html.EscapeString(truncateRunes(content, 2*snippetRadius))
+	}
+
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	re := regexp.MustCompile(`(?i)` + strings.Join(quoted, "|"))
+
+	// Center the excerpt on the first match, keeping rune boundaries intact
+	start, end := 0, len(content)
+	if loc := re.FindStringIndex(content); loc != nil {
+		start = loc[0]
+		for i := 0; i < snippetRadius && start > 0; i++ {
+			_, size := utf8.DecodeLastRuneInString(content[:start])
+			start -= size
+		}
+		end = loc[1]
+		for i := 0; i < snippetRadius && end < len(content); i++ {
+			_, size := utf8.DecodeRuneInString(content[end:])
+			end += size
+		}
+	} else {
+		end = len(truncateRunes(content, 2*snippetRadius))
+	}
+	excerpt := content[start:end]
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	last := 0
+	for _, loc := range re.FindAllStringIndex(excerpt, -1) {
+		b.WriteString(html.EscapeString(excerpt[last:loc[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(excerpt[loc[0]:loc[1]]))
+		b.WriteString("</mark>")
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(excerpt[last:]))
+	if end < len(content) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
+
+func truncateRunes(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
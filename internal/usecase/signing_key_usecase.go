@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"blog-api/internal/entity"
+	"blog-api/pkg/jwt"
+	"context"
+	"log"
+	"time"
+)
+
+type SigningKeyUsecase interface {
+	// RotateKeys creates a new signing key when the current one is due,
+	// drops keys no token can use anymore and loads the rest into the key set
+	RotateKeys(ctx context.Context, now time.Time) error
+}
+
+type signingKeyUsecase struct {
+	signingKeyRepo   SigningKeyRepository
+	keys             *jwt.KeySet
+	algorithm        string
+	rotationInterval time.Duration
+	overlap          time.Duration
+	accessTokenTTL   time.Duration
+}
+
+// NewSigningKeyUsecase rotates the keys in the key set every rotationInterval.
+// A new key is published overlap before it starts signing, which has to
+// leave every replica and verifier time to load it.
+func NewSigningKeyUsecase(signingKeyRepo SigningKeyRepository, keys *jwt.KeySet, algorithm string, rotationInterval, overlap, accessTokenTTL time.Duration) SigningKeyUsecase {
+	return &signingKeyUsecase{
+		signingKeyRepo:   signingKeyRepo,
+		keys:             keys,
+		algorithm:        algorithm,
+		rotationInterval: rotationInterval,
+		overlap:          overlap,
+		accessTokenTTL:   accessTokenTTL,
+	}
+}
+
+func (u *signingKeyUsecase) RotateKeys(ctx context.Context, now time.Time) error {
+	// Replicas starting or rotating together would each create a key
+	unlock, err := u.signingKeyRepo.LockSigningKeys(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	stored, err := u.signingKeyRepo.ListSigningKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The newest key that is not retired signs now or will soon
+	var newest *entity.SigningKey
+	for _, key := range stored {
+		if key.NotAfter == nil && (newest == nil || key.NotBefore.After(newest.NotBefore)) {
+			newest = key
+		}
+	}
+
+	rotated := true
+	switch {
+	case newest == nil:
+		// Nothing can sign yet, so the first key is used right away
+		err = u.createKey(ctx, now)
+	case newest.Algorithm != u.algorithm || now.Sub(newest.NotBefore) >= u.rotationInterval:
+		err = u.createKey(ctx, now.Add(u.overlap))
+	default:
+		rotated = false
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := u.signingKeyRepo.DeleteExpiredSigningKeys(ctx, now); err != nil {
+		return err
+	}
+	if rotated {
+		if stored, err = u.signingKeyRepo.ListSigningKeys(ctx); err != nil {
+			return err
+		}
+	}
+	return u.load(stored, now)
+}
+
+// createKey stores a new key that signs from notBefore. The keys it replaces
+// stay valid until the tokens they signed until then have expired.
+func (u *signingKeyUsecase) createKey(ctx context.Context, notBefore time.Time) error {
+	key, err := jwt.GenerateKey(u.algorithm)
+	if err != nil {
+		return err
+	}
+	privateKey, err := key.PrivateKeyPEM()
+	if err != nil {
+		return err
+	}
+
+	err = u.signingKeyRepo.RotateSigningKey(ctx, &entity.SigningKey{
+		ID:         key.ID,
+		Algorithm:  key.Algorithm,
+		PrivateKey: privateKey,
+		NotBefore:  notBefore,
+	}, notBefore.Add(u.accessTokenTTL))
+	if err != nil {
+		return err
+	}
+	log.Printf("Created %s signing key %s, signing from %s", key.Algorithm, key.ID, notBefore.Format(time.RFC3339))
+	return nil
+}
+
+// load replaces the keys of the key set with the stored ones still in use
+func (u *signingKeyUsecase) load(stored []*entity.SigningKey, now time.Time) error {
+	keys := make([]*jwt.Key, 0, len(stored))
+	for _, signingKey := range stored {
+		if signingKey.NotAfter != nil && !now.Before(*signingKey.NotAfter) {
+			continue
+		}
+
+		key, err := jwt.ParseKey(signingKey.ID, signingKey.Algorithm, signingKey.PrivateKey)
+		if err != nil {
+			return err
+		}
+		key.NotBefore = signingKey.NotBefore
+		if signingKey.NotAfter != nil {
+			key.NotAfter = *signingKey.NotAfter
+		}
+		keys = append(keys, key)
+	}
+	u.keys.Replace(keys)
+	return nil
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"strings"
+	"unicode"
+)
+
+// transliterations maps non-ASCII letters to their closest ASCII spelling
+var transliterations = buildTransliterations(
+	// Latin-1 Supplement and Latin Extended-A
+	"àáâãäåāăą", "a", "çćĉċč", "c", "ďđð", "d", "èéêëēĕėęě", "e", "ĝğġģ", "g",
+	"ĥħ", "h", "ìíîïĩīĭįı", "i", "ĵ", "j", "ķ", "k", "ĺļľŀł", "l", "ñńņňŉ", "n",
+	"òóôõöøōŏő", "o", "ŕŗř", "r", "śŝşšș", "s", "ţťŧț", "t", "ùúûüũūŭůűų", "u",
+	"ŵ", "w", "ýÿŷ", "y", "źżž", "z", "ß", "ss", "æ", "ae", "œ", "oe", "þ", "th", "ĳ", "ij",
+	// Cyrillic
+	"а", "a", "б", "b", "в", "v", "г", "g", "д", "d", "еэ", "e", "ё", "yo", "ж", "zh",
+	"з", "z", "иі", "i", "й", "y", "к", "k", "л", "l", "м", "m", "н", "n", "о", "o",
+	"п", "p", "р", "r", "с", "s", "т", "t", "у", "u", "ф", "f", "х", "kh", "ц", "ts",
+	"ч", "ch", "ш", "sh", "щ", "shch", "ы", "y", "ю", "yu", "я", "ya", "ґ", "g",
+	"є", "ye", "ї", "yi", "ъь", "",
+	// Greek
+	"αά", "a", "β", "v", "γ", "g", "δ", "d", "εέ", "e", "ζ", "z", "ηή", "i", "θ", "th",
+	"ιίϊΐ", "i", "κ", "k", "λ", "l", "μ", "m", "ν", "n", "ξ", "x", "οό", "o", "π", "p",
+	"ρ", "r", "σς", "s", "τ", "t", "υύϋΰ", "y", "φ", "f", "χ", "ch", "ψ", "ps", "ωώ", "o",
+)
+
+// buildTransliterations maps every rune of pairs[i] to pairs[i+1]
+func buildTransliterations(pairs ...string) map[rune]string {
+	m := make(map[rune]string)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		for _, r := range pairs[i] {
+			m[r] = pairs[i+1]
+		}
+	}
+	return m
+}
+
+// slugify turns a name into a lowercase, dash separated URL segment,
+// transliterating accented Latin, Cyrillic and Greek letters to ASCII
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if s, ok := transliterations[r]; ok {
+			b.WriteString(s)
+			dash = false
+		} else if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
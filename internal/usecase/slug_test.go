@@ -0,0 +1,26 @@
+package usecase
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Hello, World!", "hello-world"},
+		{"  Go 1.22: what's new?  ", "go-1-22-what-s-new"},
+		{"Crème brûlée à la carte", "creme-brulee-a-la-carte"},
+		{"Straße, Æsop, Łódź", "strasse-aesop-lodz"},
+		{"Привет, мир", "privet-mir"},
+		{"Щука и ёж", "shchuka-i-yozh"},
+		{"Αθήνα και Ψυχή", "athina-kai-psychi"},
+		{"日本語 title", "title"},
+		{"!!! ??? ---", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := slugify(tt.name); got != tt.want {
+			t.Errorf("slugify(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"blog-api/internal/entity"
+	"blog-api/internal/repository"
+	"blog-api/pkg/auth"
+	"blog-api/pkg/jwt"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+)
+
+// Refresh exchanges a refresh token for a new token pair. Refresh tokens are
+// used once: presenting one again means it was copied, so the whole family
+// is revoked and the session has to log in again.
+func (u *userUsecase) Refresh(ctx context.Context, refreshToken string) (*entity.TokenPair, error) {
+	stored, err := u.tokenRepo.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, orNotFound(err, ErrInvalidRefreshToken)
+	}
+	if stored.RevokedAt != nil || !time.Now().Before(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	used, err := u.tokenRepo.UseRefreshToken(ctx, stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		log.Printf("Refresh token reuse detected for user %d, revoking token family %s", stored.UserID, stored.FamilyID)
+		if err := u.tokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Read the user again, the role may have changed since the last refresh
+	user, err := u.userRepo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, orNotFound(err, ErrInvalidRefreshToken)
+	}
+	return u.issueTokens(ctx, user, stored.FamilyID)
+}
+
+// Logout revokes the access token until it expires, along with the session
+// of the refresh token when one is given
+func (u *userUsecase) Logout(ctx context.Context, principal *auth.Principal, refreshToken string) error {
+	if err := u.tokenRepo.RevokeAccessToken(ctx, principal.TokenID, principal.ExpiresAt); err != nil {
+		return err
+	}
+	if refreshToken == "" {
+		return nil
+	}
+
+	stored, err := u.tokenRepo.GetRefreshToken(ctx, hashToken(refreshToken))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Users can only end their own sessions
+	if stored.UserID != principal.UserID {
+		return nil
+	}
+	return u.tokenRepo.RevokeFamily(ctx, stored.FamilyID)
+}
+
+// IsTokenRevoked reports whether an access token was revoked by logging out,
+// or was issued for a role its user no longer has. Checking the role on
+// every request makes role changes apply to tokens already issued.
+func (u *userUsecase) IsTokenRevoked(ctx context.Context, claims *jwt.Claims) (bool, error) {
+	revoked, err := u.tokenRepo.IsAccessTokenRevoked(ctx, claims.ID)
+	if err != nil || revoked {
+		return revoked, err
+	}
+
+	user, err := u.userRepo.GetByID(ctx, claims.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return user.Role != claims.Role, nil
+}
+
+// issueTokens creates an access token and a refresh token of the given family
+func (u *userUsecase) issueTokens(ctx context.Context, user *entity.User, familyID string) (*entity.TokenPair, error) {
+	accessToken, err := u.signer.GenerateAccessToken(user.ID, user.Role, auth.RolePermissions(user.Role), u.accessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only a hash is stored, so a leaked database cannot be used to refresh
+	err = u.tokenRepo.CreateRefreshToken(ctx, &entity.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(u.refreshTokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    u.accessTokenTTL,
+	}, nil
+}
+
+// newFamilyID returns a random ID for the token family of a new session
+func newFamilyID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// newRefreshToken returns an unguessable refresh token that is safe in URLs
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,58 +2,150 @@ package usecase
 
 import (
 	"blog-api/internal/entity"
-	"blog-api/internal/repository/mysql"
+	"blog-api/internal/repository"
+	"blog-api/pkg/auth"
 	"blog-api/pkg/jwt"
+	"context"
+	"errors"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	// ErrUserNotFound is returned when a user does not exist
+	ErrUserNotFound = notFoundError("user not found")
+
+	// ErrUserExists is returned when registering a username or email that is already taken
+	ErrUserExists = conflictError("username or email already exists")
+
+	// ErrInvalidCredentials is returned for unknown usernames and wrong passwords alike
+	ErrInvalidCredentials = &Error{Kind: ErrUnauthorized, Message: "invalid username or password"}
+
+	// ErrInvalidRole is returned for roles that do not exist
+	ErrInvalidRole = validationError("role", "role must be one of user, author or admin")
+
+	// ErrRoleNotSelectable is returned when registering with a role other
+	// than user or author; admins are appointed
+	ErrRoleNotSelectable = validationError("role", "please specify your role, either 'author' or 'user'")
+
+	// ErrChangeOwnRole is returned when admins change their own role, which
+	// could leave nobody to manage users
+	ErrChangeOwnRole = forbiddenError("admins cannot change their own role")
+
+	// ErrNotPermitted is returned when a user lacks the permission for an action
+	ErrNotPermitted = forbiddenError("you do not have permission to do this")
+
+	// ErrInvalidRefreshToken is returned for unknown, expired, revoked and reused refresh tokens
+	ErrInvalidRefreshToken = &Error{Kind: ErrUnauthorized, Message: "invalid or expired refresh token"}
+)
+
 type UserUsecase interface {
-	Register(user *entity.User) error
-	Login(username, password string) (string, error)
-	GetByUsernameOrEmail(username, email string) (*entity.User, error)
-	GenerateJWTToken(userID int, role string) (string, error)
+	Register(ctx context.Context, user *entity.User) error
+	Login(ctx context.Context, username, password string) (*entity.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*entity.TokenPair, error)
+	Logout(ctx context.Context, principal *auth.Principal, refreshToken string) error
+	IsTokenRevoked(ctx context.Context, claims *jwt.Claims) (bool, error)
+	GetByID(ctx context.Context, id int) (*entity.User, error)
+	GetByUsernameOrEmail(ctx context.Context, username, email string) (*entity.User, error)
+	ChangeRole(ctx context.Context, actor *auth.Principal, id int, role string) (*entity.User, error)
 }
 
 type userUsecase struct {
-	userRepo  *mysql.UserRepository
-	jwtSecret string
+	userRepo        UserRepository
+	tokenRepo       TokenRepository
+	signer          *jwt.Signer
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
 }
 
-func NewUserUsecase(userRepo *mysql.UserRepository, jwtSecret string) UserUsecase {
+func NewUserUsecase(userRepo UserRepository, tokenRepo TokenRepository, signer *jwt.Signer, accessTokenTTL, refreshTokenTTL time.Duration) UserUsecase {
 	return &userUsecase{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
+		userRepo:        userRepo,
+		tokenRepo:       tokenRepo,
+		signer:          signer,
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
 	}
 }
 
-func (u *userUsecase) Register(user *entity.User) error {
-	return u.userRepo.Create(user)
+func (u *userUsecase) Register(ctx context.Context, user *entity.User) error {
+	if user.Role != auth.RoleUser && user.Role != auth.RoleAuthor {
+		return ErrRoleNotSelectable
+	}
+
+	_, err := u.userRepo.GetByUsernameOrEmail(ctx, user.Username, user.Email)
+	if err == nil {
+		return ErrUserExists
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	return u.userRepo.Create(ctx, user)
 }
 
-func (u *userUsecase) Login(username, password string) (string, error) {
+// Login checks the credentials and starts a session with a new token family
+func (u *userUsecase) Login(ctx context.Context, username, password string) (*entity.TokenPair, error) {
 
-	user, err := u.userRepo.GetByUsername(username)
+	user, err := u.userRepo.GetByUsername(ctx, username)
 	if err != nil {
-		return "", err
+		return nil, orNotFound(err, ErrInvalidCredentials)
 	}
 
 	// Compare the hashed password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", err
+		return nil, ErrInvalidCredentials
+	}
+
+	familyID, err := newFamilyID()
+	if err != nil {
+		return nil, err
 	}
+	return u.issueTokens(ctx, user, familyID)
+}
 
-	token, err := jwt.GenerateJWTToken(user.ID, user.Role, u.jwtSecret)
+func (u *userUsecase) GetByID(ctx context.Context, id int) (*entity.User, error) {
+	user, err := u.userRepo.GetByID(ctx, id)
 	if err != nil {
-		return "", err
+		return nil, orNotFound(err, ErrUserNotFound)
 	}
-	return token, nil
+	return user, nil
 }
 
-func (u *userUsecase) GetByUsernameOrEmail(username, email string) (*entity.User, error) {
-	return u.userRepo.GetByUsernameOrEmail(username, email)
+func (u *userUsecase) GetByUsernameOrEmail(ctx context.Context, username, email string) (*entity.User, error) {
+	user, err := u.userRepo.GetByUsernameOrEmail(ctx, username, email)
+	if err != nil {
+		return nil, orNotFound(err, ErrUserNotFound)
+	}
+	return user, nil
 }
 
-func (u *userUsecase) GenerateJWTToken(userID int, role string) (string, error) {
-	return jwt.GenerateJWTToken(userID, role, u.jwtSecret)
+// ChangeRole gives a user another role and ends their sessions. Their access
+// tokens carry the old role, so they are rejected from the next request on,
+// and their refresh tokens are revoked; they sign in again to get tokens
+// with the new role's permissions.
+func (u *userUsecase) ChangeRole(ctx context.Context, actor *auth.Principal, id int, role string) (*entity.User, error) {
+	if !actor.Can(auth.PermUserManage) {
+		return nil, ErrNotPermitted
+	}
+	if !auth.ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	if id == actor.UserID {
+		return nil, ErrChangeOwnRole
+	}
+
+	user, err := u.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, orNotFound(err, ErrUserNotFound)
+	}
+	if err := u.userRepo.UpdateRole(ctx, id, role); err != nil {
+		return nil, err
+	}
+
+	if err := u.tokenRepo.RevokeUserFamilies(ctx, id); err != nil {
+		return nil, err
+	}
+	user.Role = role
+	return user, nil
 }
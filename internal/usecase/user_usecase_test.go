@@ -0,0 +1,194 @@
+package usecase_test
+
+import (
+	"blog-api/internal/entity"
+	"blog-api/internal/repository/memory"
+	"blog-api/internal/usecase"
+	"blog-api/pkg/auth"
+	"blog-api/pkg/jwt"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testPassword = "password123"
+
+func newUserUsecase(t *testing.T, db *memory.DB) usecase.UserUsecase {
+	t.Helper()
+	key, err := jwt.GenerateKey(jwt.AlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := jwt.NewKeySet()
+	keys.Replace([]*jwt.Key{key})
+	signer := jwt.NewSigner(keys, "blog-api", "blog-api")
+	return usecase.NewUserUsecase(memory.NewUserRepository(db), memory.NewTokenRepository(db), signer, time.Minute, time.Hour)
+}
+
+func register(t *testing.T, users usecase.UserUsecase, username, role string) *entity.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &entity.User{Username: username, Email: username + "@example.com", Password: string(hash), Role: role}
+	if err := users.Register(context.Background(), user); err != nil {
+		t.Fatalf("Register(%q): %v", username, err)
+	}
+	return user
+}
+
+// accessClaims returns the claims of an access token issued to the user
+func accessClaims(userID int, role string) *jwt.Claims {
+	claims := &jwt.Claims{UserID: userID, Role: role}
+	claims.ID = "access-token"
+	return claims
+}
+
+func TestUserRegisterAndLogin(t *testing.T) {
+	ctx := context.Background()
+	users := newUserUsecase(t, memory.NewDB())
+	register(t, users, "alice", auth.RoleAuthor)
+
+	err := users.Register(ctx, &entity.User{Username: "alice", Email: "other@example.com", Role: auth.RoleUser})
+	if !errors.Is(err, usecase.ErrUserExists) {
+		t.Errorf("registering a taken username: error = %v, want ErrUserExists", err)
+	}
+	err = users.Register(ctx, &entity.User{Username: "mallory", Email: "mallory@example.com", Role: auth.RoleAdmin})
+	if !errors.Is(err, usecase.ErrRoleNotSelectable) {
+		t.Errorf("registering as an admin: error = %v, want ErrRoleNotSelectable", err)
+	}
+
+	tokens, err := users.Login(ctx, "alice", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Error("Login returned an empty token")
+	}
+
+	for _, credentials := range [][2]string{{"alice", "wrong password"}, {"nobody", testPassword}} {
+		if _, err := users.Login(ctx, credentials[0], credentials[1]); !errors.Is(err, usecase.ErrInvalidCredentials) {
+			t.Errorf("Login(%q, %q): error = %v, want ErrInvalidCredentials", credentials[0], credentials[1], err)
+		}
+	}
+}
+
+func TestUserRefreshRotation(t *testing.T) {
+	ctx := context.Background()
+	users := newUserUsecase(t, memory.NewDB())
+	register(t, users, "alice", auth.RoleAuthor)
+
+	login, err := users.Login(ctx, "alice", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refreshed, err := users.Refresh(ctx, login.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.RefreshToken == login.RefreshToken {
+		t.Error("Refresh returned the same refresh token")
+	}
+
+	// A second session is unaffected by what happens to the first
+	other, err := users.Login(ctx, "alice", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Presenting a used refresh token again revokes the whole family,
+	// including the token it was exchanged for
+	if _, err := users.Refresh(ctx, login.RefreshToken); !errors.Is(err, usecase.ErrInvalidRefreshToken) {
+		t.Errorf("reusing a refresh token: error = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := users.Refresh(ctx, refreshed.RefreshToken); !errors.Is(err, usecase.ErrInvalidRefreshToken) {
+		t.Errorf("refreshing after reuse was detected: error = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := users.Refresh(ctx, other.RefreshToken); err != nil {
+		t.Errorf("the other session was revoked as well: %v", err)
+	}
+
+	if _, err := users.Refresh(ctx, "unknown"); !errors.Is(err, usecase.ErrInvalidRefreshToken) {
+		t.Errorf("refreshing an unknown token: error = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestUserLogout(t *testing.T) {
+	ctx := context.Background()
+	users := newUserUsecase(t, memory.NewDB())
+	user := register(t, users, "alice", auth.RoleAuthor)
+
+	tokens, err := users.Login(ctx, "alice", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := accessClaims(user.ID, user.Role)
+	if revoked, err := users.IsTokenRevoked(ctx, claims); err != nil || revoked {
+		t.Fatalf("IsTokenRevoked before logging out = %v, %v", revoked, err)
+	}
+
+	session := &auth.Principal{UserID: user.ID, Role: user.Role, TokenID: claims.ID, ExpiresAt: time.Now().Add(time.Minute)}
+	if err := users.Logout(ctx, session, tokens.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+
+	revoked, err := users.IsTokenRevoked(ctx, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !revoked {
+		t.Error("the access token is not revoked after logging out")
+	}
+	if _, err := users.Refresh(ctx, tokens.RefreshToken); !errors.Is(err, usecase.ErrInvalidRefreshToken) {
+		t.Errorf("refreshing after logging out: error = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestUserChangeRole(t *testing.T) {
+	ctx := context.Background()
+	users := newUserUsecase(t, memory.NewDB())
+	user := register(t, users, "alice", auth.RoleUser)
+	admin := principal(100, auth.RoleAdmin)
+
+	tokens, err := users.Login(ctx, "alice", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := users.ChangeRole(ctx, principal(user.ID, auth.RoleUser), user.ID, auth.RoleAdmin); !errors.Is(err, usecase.ErrNotPermitted) {
+		t.Errorf("a user changed their own role: error = %v, want ErrNotPermitted", err)
+	}
+	if _, err := users.ChangeRole(ctx, admin, admin.UserID, auth.RoleUser); !errors.Is(err, usecase.ErrChangeOwnRole) {
+		t.Errorf("an admin changed their own role: error = %v, want ErrChangeOwnRole", err)
+	}
+	if _, err := users.ChangeRole(ctx, admin, user.ID, "owner"); !errors.Is(err, usecase.ErrInvalidRole) {
+		t.Errorf("changing to an unknown role: error = %v, want ErrInvalidRole", err)
+	}
+
+	changed, err := users.ChangeRole(ctx, admin, user.ID, auth.RoleAuthor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed.Role != auth.RoleAuthor {
+		t.Errorf("Role = %s, want author", changed.Role)
+	}
+
+	// Access tokens issued for the old role stop working right away
+	if revoked, err := users.IsTokenRevoked(ctx, accessClaims(user.ID, auth.RoleUser)); err != nil || !revoked {
+		t.Errorf("IsTokenRevoked for the old role = %v, %v, want revoked", revoked, err)
+	}
+	if revoked, err := users.IsTokenRevoked(ctx, accessClaims(user.ID, auth.RoleAuthor)); err != nil || revoked {
+		t.Errorf("IsTokenRevoked for the new role = %v, %v, want valid", revoked, err)
+	}
+
+	// Existing sessions end, so the new role only applies after signing in again
+	if _, err := users.Refresh(ctx, tokens.RefreshToken); !errors.Is(err, usecase.ErrInvalidRefreshToken) {
+		t.Errorf("refreshing after a role change: error = %v, want ErrInvalidRefreshToken", err)
+	}
+}
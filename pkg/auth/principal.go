@@ -0,0 +1,51 @@
+// Package auth carries the authenticated caller of a request in its context
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// Principal is the authenticated caller of a request, as described by their
+// access token. The scopes of the token are the permissions of the user's
+// role when it was issued.
+type Principal struct {
+	UserID  int
+	Role    string
+	Scopes  []string
+	TokenID string
+	// ExpiresAt is when the access token expires
+	ExpiresAt time.Time
+}
+
+// Can reports whether the access token was granted the permission
+func (p *Principal) Can(permission string) bool {
+	for _, s := range p.Scopes {
+		if s == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the principal
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// FromContext returns the principal of an authenticated request
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(contextKey{}).(*Principal)
+	return principal, ok
+}
+
+// UserID returns the ID of the authenticated user
+func UserID(ctx context.Context) (int, bool) {
+	principal, ok := FromContext(ctx)
+	if !ok {
+		return 0, false
+	}
+	return principal.UserID, true
+}
@@ -0,0 +1,49 @@
+package auth
+
+// Roles a user can have. Users and authors pick theirs when registering;
+// admins are appointed.
+const (
+	RoleUser   = "user"
+	RoleAuthor = "author"
+	RoleAdmin  = "admin"
+)
+
+// Permissions granted by the roles. A permission ending in ":any" extends
+// an action users may take on their own records to everyone's.
+const (
+	PermBlogWrite          = "blog:write"
+	PermBlogUpdateAny      = "blog:update:any"
+	PermBlogDeleteAny      = "blog:delete:any"
+	PermCommentWrite       = "comment:write"
+	PermCommentModerate    = "comment:moderate"
+	PermCommentModerateAny = "comment:moderate:any"
+	PermCommentDeleteAny   = "comment:delete:any"
+	PermCategoryManage     = "category:manage"
+	PermMediaManage        = "media:manage"
+	PermUserManage         = "user:manage"
+)
+
+var rolePermissions = map[string][]string{
+	RoleUser: {PermCommentWrite},
+	RoleAuthor: {
+		PermCommentWrite, PermBlogWrite, PermCommentModerate,
+		PermCategoryManage, PermMediaManage,
+	},
+	RoleAdmin: {
+		PermCommentWrite, PermBlogWrite, PermCommentModerate,
+		PermCategoryManage, PermMediaManage,
+		PermBlogUpdateAny, PermBlogDeleteAny, PermCommentModerateAny, PermCommentDeleteAny,
+		PermUserManage,
+	},
+}
+
+// ValidRole reports whether the role exists
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RolePermissions lists the permissions of a role; unknown roles have none
+func RolePermissions(role string) []string {
+	return append([]string(nil), rolePermissions[role]...)
+}
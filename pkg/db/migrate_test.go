@@ -0,0 +1,115 @@
+package db
+
+import (
+	"blog-api/config"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 || migrations[0].Version != 1 {
+		t.Fatal("the baseline migration 0001 is missing")
+	}
+	for i, migration := range migrations {
+		if i > 0 && migration.Version <= migrations[i-1].Version {
+			t.Errorf("migration %d_%s is out of order", migration.Version, migration.Name)
+		}
+	}
+}
+
+// TestMigrationsRoundTrip rolls every migration back and applies it again,
+// on top of the ones before it, so down migrations that leave something
+// behind the up migration creates are caught. It needs a MySQL server and
+// is skipped unless MYSQL_TEST_HOST is set, e.g.
+//
+//	docker run -p 3306:3306 -e MYSQL_ALLOW_EMPTY_PASSWORD=yes mysql:8
+//	MYSQL_TEST_HOST=127.0.0.1 go test ./pkg/db -run RoundTrip
+//
+// MYSQL_TEST_PORT, MYSQL_TEST_USER and MYSQL_TEST_PASSWORD default to
+// 3306, root and no password. The blog_api_migrations_test database is
+// dropped and created again.
+func TestMigrationsRoundTrip(t *testing.T) {
+	host := os.Getenv("MYSQL_TEST_HOST")
+	if host == "" {
+		t.Skip("MYSQL_TEST_HOST is not set")
+	}
+	cfg := &config.Config{
+		DBHost:     host,
+		DBPort:     envOr("MYSQL_TEST_PORT", "3306"),
+		DBUser:     envOr("MYSQL_TEST_USER", "root"),
+		DBPassword: os.Getenv("MYSQL_TEST_PASSWORD"),
+		DBName:     "blog_api_migrations_test",
+	}
+	createDatabase(t, cfg)
+
+	migrator, err := NewMigrator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer migrator.Close()
+
+	ctx := context.Background()
+	all := migrator.migrations
+	for n := 1; n <= len(all); n++ {
+		migration := all[n-1]
+		migrator.migrations = all[:n]
+
+		if _, err := migrator.Up(ctx); err != nil {
+			t.Fatalf("up: %v", err)
+		}
+		if _, err := migrator.Down(ctx, 1); err != nil {
+			t.Fatalf("down %d_%s: %v", migration.Version, migration.Name, err)
+		}
+		if _, err := migrator.Up(ctx); err != nil {
+			t.Fatalf("up %d_%s after rolling it back: %v", migration.Version, migration.Name, err)
+		}
+	}
+
+	// All the way down and up again
+	if _, err := migrator.Down(ctx, len(all)); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("up after rolling everything back: %v", err)
+	}
+	if len(applied) != len(all) {
+		t.Errorf("applied %d migrations, want %d", len(applied), len(all))
+	}
+}
+
+// createDatabase creates an empty database for the test, dropped afterwards
+func createDatabase(t *testing.T, cfg *config.Config) {
+	t.Helper()
+	server, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/", cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		server.Exec("DROP DATABASE IF EXISTS " + cfg.DBName)
+		server.Close()
+	})
+
+	if _, err := server.Exec("DROP DATABASE IF EXISTS " + cfg.DBName); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.Exec("CREATE DATABASE " + cfg.DBName); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
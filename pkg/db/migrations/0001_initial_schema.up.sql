@@ -1,3 +1,6 @@
+-- The schema scripts/init.sql created before migrations existed, unchanged.
+-- IF NOT EXISTS lets databases set up that way adopt this migration as their
+-- baseline; every later change is a migration of its own.
 
 CREATE TABLE IF NOT EXISTS users (
     id INT AUTO_INCREMENT PRIMARY KEY,
@@ -16,7 +19,6 @@ CREATE TABLE IF NOT EXISTS blogs (
     FOREIGN KEY (user_id) REFERENCES users(id)
 );
 
-
 CREATE TABLE IF NOT EXISTS comments (
     id INT AUTO_INCREMENT PRIMARY KEY,
     content TEXT NOT NULL,
@@ -24,4 +26,4 @@ CREATE TABLE IF NOT EXISTS comments (
     blog_id INT NOT NULL,
     FOREIGN KEY (user_id) REFERENCES users(id),
     FOREIGN KEY (blog_id) REFERENCES blogs(id) ON DELETE CASCADE
-);
\ No newline at end of file
+);
@@ -0,0 +1,8 @@
+-- idx_blogs_user_id is swapped for a plain index, the user_id foreign key
+-- needs one
+ALTER TABLE blogs
+    ADD INDEX user_id (user_id),
+    DROP INDEX idx_blogs_user_id,
+    DROP INDEX idx_blogs_title,
+    DROP INDEX idx_blogs_created_at,
+    DROP COLUMN created_at;
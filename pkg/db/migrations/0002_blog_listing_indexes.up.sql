@@ -0,0 +1,6 @@
+-- Existing blogs get the time of the migration as their creation time
+ALTER TABLE blogs
+    ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD INDEX idx_blogs_user_id (user_id),
+    ADD INDEX idx_blogs_created_at (created_at, id),
+    ADD INDEX idx_blogs_title (title, id);
@@ -0,0 +1 @@
+ALTER TABLE blogs DROP INDEX ft_blogs_title_content;
@@ -0,0 +1 @@
+ALTER TABLE blogs ADD FULLTEXT INDEX ft_blogs_title_content (title, content);
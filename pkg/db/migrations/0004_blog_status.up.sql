@@ -0,0 +1,8 @@
+ALTER TABLE blogs
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    ADD COLUMN published_at TIMESTAMP NULL,
+    ADD INDEX idx_blogs_status (status);
+
+-- Blogs written before the workflow existed were public, so they stay
+-- published rather than turning into drafts
+UPDATE blogs SET status = 'published', published_at = created_at;
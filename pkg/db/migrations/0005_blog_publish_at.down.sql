@@ -0,0 +1,4 @@
+ALTER TABLE blogs
+    DROP INDEX idx_blogs_status,
+    ADD INDEX idx_blogs_status (status),
+    DROP COLUMN publish_at;
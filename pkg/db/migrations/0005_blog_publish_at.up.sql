@@ -0,0 +1,4 @@
+ALTER TABLE blogs
+    ADD COLUMN publish_at TIMESTAMP NULL,
+    DROP INDEX idx_blogs_status,
+    ADD INDEX idx_blogs_status (status, publish_at);
@@ -0,0 +1,15 @@
+CREATE TABLE blog_revisions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    blog_id INT NOT NULL,
+    revision INT NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    thumbnail VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_blog_revisions_revision (blog_id, revision),
+    FOREIGN KEY (blog_id) REFERENCES blogs(id) ON DELETE CASCADE
+);
+
+-- Every blog starts with its current version as the first revision
+INSERT INTO blog_revisions (blog_id, revision, title, content, thumbnail, created_at)
+SELECT id, 1, title, content, thumbnail, created_at FROM blogs;
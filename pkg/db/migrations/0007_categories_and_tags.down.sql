@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS blog_tags;
+DROP TABLE IF EXISTS tags;
+
+ALTER TABLE blogs
+    DROP FOREIGN KEY fk_blogs_category_id,
+    DROP COLUMN category_id;
+
+DROP TABLE IF EXISTS categories;
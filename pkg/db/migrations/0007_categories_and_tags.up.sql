@@ -0,0 +1,27 @@
+CREATE TABLE categories (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    slug VARCHAR(120) NOT NULL,
+    parent_id INT NULL,
+    UNIQUE KEY uq_categories_slug (slug),
+    FOREIGN KEY (parent_id) REFERENCES categories(id)
+);
+
+ALTER TABLE blogs
+    ADD COLUMN category_id INT NULL,
+    ADD CONSTRAINT fk_blogs_category_id FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL;
+
+CREATE TABLE tags (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    UNIQUE KEY uq_tags_name (name)
+);
+
+CREATE TABLE blog_tags (
+    blog_id INT NOT NULL,
+    tag_id INT NOT NULL,
+    PRIMARY KEY (blog_id, tag_id),
+    INDEX idx_blog_tags_tag_id (tag_id),
+    FOREIGN KEY (blog_id) REFERENCES blogs(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
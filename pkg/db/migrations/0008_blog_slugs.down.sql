@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS blog_slug_redirects;
+
+ALTER TABLE blogs
+    DROP INDEX uq_blogs_slug,
+    DROP COLUMN slug;
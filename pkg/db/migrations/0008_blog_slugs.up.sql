@@ -0,0 +1,14 @@
+-- Existing blogs are left without a slug here: the server gives them one on
+-- startup, slugified by the application the way new blogs are, which SQL
+-- cannot do for accented, Cyrillic and Greek titles. The unique key allows
+-- any number of NULLs until then.
+ALTER TABLE blogs
+    ADD COLUMN slug VARCHAR(255) NULL AFTER title,
+    ADD UNIQUE KEY uq_blogs_slug (slug);
+
+CREATE TABLE blog_slug_redirects (
+    slug VARCHAR(255) PRIMARY KEY,
+    blog_id INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (blog_id) REFERENCES blogs(id) ON DELETE CASCADE
+);
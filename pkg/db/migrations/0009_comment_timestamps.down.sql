@@ -0,0 +1,7 @@
+-- idx_comments_blog_id is swapped for a plain index, the blog_id foreign key
+-- needs one
+ALTER TABLE comments
+    ADD INDEX blog_id (blog_id),
+    DROP INDEX idx_comments_blog_id,
+    DROP COLUMN updated_at,
+    DROP COLUMN created_at;
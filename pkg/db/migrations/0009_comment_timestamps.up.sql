@@ -0,0 +1,5 @@
+-- Existing comments get the time of the migration as their creation time
+ALTER TABLE comments
+    ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    ADD INDEX idx_comments_blog_id (blog_id, created_at, id);
@@ -0,0 +1,11 @@
+-- Replies would lose their place in the thread, so they go with it
+DELETE FROM comments WHERE parent_id IS NOT NULL;
+
+ALTER TABLE comments
+    DROP FOREIGN KEY fk_comments_parent_id,
+    DROP INDEX idx_comments_root_id,
+    DROP INDEX idx_comments_blog_id,
+    ADD INDEX idx_comments_blog_id (blog_id, created_at, id),
+    DROP COLUMN depth,
+    DROP COLUMN root_id,
+    DROP COLUMN parent_id;
@@ -0,0 +1,8 @@
+ALTER TABLE comments
+    ADD COLUMN parent_id INT NULL AFTER blog_id,
+    ADD COLUMN root_id INT NULL AFTER parent_id,
+    ADD COLUMN depth INT NOT NULL DEFAULT 0 AFTER root_id,
+    ADD CONSTRAINT fk_comments_parent_id FOREIGN KEY (parent_id) REFERENCES comments(id) ON DELETE CASCADE,
+    DROP INDEX idx_comments_blog_id,
+    ADD INDEX idx_comments_blog_id (blog_id, parent_id, created_at, id),
+    ADD INDEX idx_comments_root_id (root_id);
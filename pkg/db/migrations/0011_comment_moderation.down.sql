@@ -0,0 +1,5 @@
+ALTER TABLE comments
+    DROP INDEX idx_comments_status,
+    DROP COLUMN status;
+
+ALTER TABLE blogs DROP COLUMN comment_mode;
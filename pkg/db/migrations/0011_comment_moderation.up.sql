@@ -0,0 +1,9 @@
+ALTER TABLE blogs ADD COLUMN comment_mode VARCHAR(20) NOT NULL DEFAULT 'open' AFTER status;
+
+ALTER TABLE comments
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'approved' AFTER depth,
+    ADD INDEX idx_comments_status (status, blog_id);
+
+-- Comments written before moderation existed were public, so they stay
+-- approved rather than landing in a moderation queue
+UPDATE comments SET status = 'approved';
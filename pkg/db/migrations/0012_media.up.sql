@@ -0,0 +1,13 @@
+CREATE TABLE media (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    size INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_media_user_path (user_id, path),
+    INDEX idx_media_path (path),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,11 @@
+-- Keys access tokens are signed with, shared by every replica. Private keys
+-- are stored as PEM encoded PKCS #8, so access to this table grants signing.
+CREATE TABLE signing_keys (
+    id VARCHAR(64) PRIMARY KEY,
+    algorithm VARCHAR(16) NOT NULL,
+    private_key TEXT NOT NULL,
+    not_before TIMESTAMP NOT NULL,
+    not_after TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
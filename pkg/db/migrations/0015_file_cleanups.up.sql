@@ -0,0 +1,7 @@
+-- Stored files waiting to be removed once nothing uses them. Files are
+-- checked again when due, inside a transaction that locks their row.
+CREATE TABLE file_cleanups (
+    path VARCHAR(255) PRIMARY KEY,
+    due_at TIMESTAMP NOT NULL,
+    INDEX idx_file_cleanups_due_at (due_at)
+);
@@ -4,9 +4,7 @@ import (
 	"blog-api/config"
 	"database/sql"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"strings"
 )
 
 func ConnectDB(cfg *config.Config) *sql.DB {
@@ -29,8 +27,7 @@ func ConnectDB(cfg *config.Config) *sql.DB {
 	}
 
 	// Now connect to the specific database
-	dsn = fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
-	db, err = sql.Open("mysql", dsn)
+	db, err = sql.Open("mysql", databaseDSN(cfg, "parseTime=true"))
 	if err != nil {
 		log.Fatal("Failed to connect to the database:", err)
 	}
@@ -44,27 +41,8 @@ func ConnectDB(cfg *config.Config) *sql.DB {
 	return db
 }
 
-func InitializeDB(db *sql.DB, cfg *config.Config) error {
-
-	// Read SQL script for creating tables
-	sqlFile, err := ioutil.ReadFile("scripts/init.sql")
-	if err != nil {
-		return fmt.Errorf("failed to read SQL script: %v", err)
-	}
-
-	// Split SQL statements (assuming statements are separated by semicolons)
-	statements := strings.Split(string(sqlFile), ";")
-
-	for i, stmt := range statements {
-		if stmt != "" {
-			_, err = db.Exec(stmt)
-			if err != nil {
-				log.Printf("Error executing statement %d: %v", i+1, err)
-				return fmt.Errorf("failed to execute SQL statement %d: %v", i+1, err)
-			}
-		}
-	}
-
-	log.Println("Tables initialized successfully.")
-	return nil
+// databaseDSN returns the DSN of the application database with the given
+// query parameters
+func databaseDSN(cfg *config.Config, params string) string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?%s", cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName, params)
 }
@@ -0,0 +1,52 @@
+package imaging
+
+// gifFrames counts the frames of a GIF by walking its blocks, without
+// decoding any pixels
+func gifFrames(data []byte) (int, error) {
+	// Header and logical screen descriptor, then the global color table
+	pos := 13
+	if len(data) < pos {
+		return 0, ErrCorrupt
+	}
+	if flags := data[10]; flags&0x80 != 0 {
+		pos += 3 << (flags&0x07 + 1)
+	}
+
+	frames := 0
+	for {
+		if pos >= len(data) {
+			return 0, ErrCorrupt
+		}
+		switch data[pos] {
+		case 0x21: // Extension: label, then data sub-blocks
+			pos += 2
+		case 0x2C: // Image descriptor, local color table, LZW code size, then data sub-blocks
+			if pos+10 > len(data) {
+				return 0, ErrCorrupt
+			}
+			flags := data[pos+9]
+			pos += 10
+			if flags&0x80 != 0 {
+				pos += 3 << (flags&0x07 + 1)
+			}
+			pos++
+			frames++
+		case 0x3B: // Trailer
+			return frames, nil
+		default:
+			return 0, ErrCorrupt
+		}
+
+		// Sub-blocks start with their length and end with an empty one
+		for {
+			if pos >= len(data) {
+				return 0, ErrCorrupt
+			}
+			length := int(data[pos])
+			pos += 1 + length
+			if length == 0 {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/webp"
+)
+
+// MaxPixels bounds width*height so a small, highly compressed file cannot
+// expand into gigabytes once decoded. Every frame of an animated GIF is
+// decoded, so their pixels add up.
+const MaxPixels = 25_000_000
+
+// MaxDimension bounds either side of an image
+const MaxDimension = 10_000
+
+// jpegQuality is used when re-encoding JPEGs and their variants
+const jpegQuality = 88
+
+var (
+	ErrUnsupportedFormat = errors.New("image must be a JPEG, PNG, GIF or WebP")
+	ErrTooManyPixels     = fmt.Errorf("image must be at most %dx%d pixels and %d megapixels", MaxDimension, MaxDimension, MaxPixels/1_000_000)
+	ErrCorrupt           = errors.New("image could not be decoded")
+)
+
+// Format describes an image format detected from its magic bytes
+type Format struct {
+	Name        string
+	ContentType string
+	Ext         string
+}
+
+var (
+	JPEG = Format{"jpeg", "image/jpeg", ".jpg"}
+	PNG  = Format{"png", "image/png", ".png"}
+	GIF  = Format{"gif", "image/gif", ".gif"}
+	WebP = Format{"webp", "image/webp", ".webp"}
+)
+
+// Image is an uploaded image with its metadata stripped, along with
+// downscaled variants keyed by width
+type Image struct {
+	Format Format
+	Data   []byte
+	Width  int
+	Height int
+
+	// VariantFormat is the format variants are encoded in. GIF variants are
+	// still images and WebP cannot be encoded, so both are encoded as PNG.
+	VariantFormat Format
+	Variants      map[int][]byte
+}
+
+// Detect identifies the image format from the magic bytes at the start of data
+func Detect(data []byte) (Format, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return JPEG, nil
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return PNG, nil
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return GIF, nil
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return WebP, nil
+	default:
+		return Format{}, ErrUnsupportedFormat
+	}
+}
+
+// Process validates an uploaded image, strips its metadata (EXIF, XMP,
+// comments) and renders a variant for each of widths. Images are never
+// upscaled: a variant wider than the image is rendered at the image's size.
+func Process(data []byte, widths []int) (*Image, error) {
+	format, err := Detect(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the dimensions from the header before decoding any pixels
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	if err := checkDimensions(config.Width, config.Height); err != nil {
+		return nil, err
+	}
+
+	img := &Image{Format: format, VariantFormat: format, Variants: make(map[int][]byte, len(widths))}
+	var still image.Image
+	var buf bytes.Buffer
+
+	switch format {
+	case JPEG:
+		decoded, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrCorrupt
+		}
+		// Stripping EXIF drops the orientation too, so apply it to the pixels
+		still = orient(decoded, jpegOrientation(data))
+		err = jpeg.Encode(&buf, still, &jpeg.Options{Quality: jpegQuality})
+		if err != nil {
+			return nil, err
+		}
+
+	case PNG:
+		decoded, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrCorrupt
+		}
+		still = decoded
+		if err := png.Encode(&buf, still); err != nil {
+			return nil, err
+		}
+
+	case GIF:
+		// Count the frames before decoding them, all of them are held in memory
+		frames, err := gifFrames(data)
+		if err != nil {
+			return nil, err
+		}
+		if frames*config.Width*config.Height > MaxPixels {
+			return nil, ErrTooManyPixels
+		}
+
+		decoded, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil || len(decoded.Image) == 0 {
+			return nil, ErrCorrupt
+		}
+		// Re-encoding keeps the frames and loop count but drops comments and
+		// application extensions such as XMP
+		if err := gif.EncodeAll(&buf, decoded); err != nil {
+			return nil, err
+		}
+		// Variants show the first frame on the full canvas
+		canvas := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+		draw.Draw(canvas, decoded.Image[0].Bounds(), decoded.Image[0], decoded.Image[0].Bounds().Min, draw.Over)
+		still = canvas
+		img.VariantFormat = PNG
+
+	case WebP:
+		// There is no WebP encoder, so the image is stripped at the container
+		// level and its variants are encoded as PNG
+		stripped, err := stripWebP(data)
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := webp.Decode(bytes.NewReader(stripped))
+		if err != nil {
+			return nil, ErrCorrupt
+		}
+		buf.Write(stripped)
+		still = decoded
+		img.VariantFormat = PNG
+	}
+
+	img.Data = buf.Bytes()
+	img.Width = still.Bounds().Dx()
+	img.Height = still.Bounds().Dy()
+
+	for _, width := range widths {
+		variant, err := encode(resize(still, width), img.VariantFormat)
+		if err != nil {
+			return nil, err
+		}
+		img.Variants[width] = variant
+	}
+	return img, nil
+}
+
+func checkDimensions(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return ErrCorrupt
+	}
+	if width > MaxDimension || height > MaxDimension || width*height > MaxPixels {
+		return ErrTooManyPixels
+	}
+	return nil
+}
+
+func encode(img image.Image, format Format) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case JPEG:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	case PNG:
+		err = png.Encode(&buf, img)
+	default:
+		err = ErrUnsupportedFormat
+	}
+	return buf.Bytes(), err
+}
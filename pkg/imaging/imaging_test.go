@@ -0,0 +1,440 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+var (
+	red   = color.RGBA{255, 0, 0, 255}
+	green = color.RGBA{0, 255, 0, 255}
+)
+
+// tinyWebP is a 1x1 lossless WebP in the simple container, there being no
+// WebP encoder to generate one
+var tinyWebP, _ = base64.StdEncoding.DecodeString("UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA==")
+
+func newImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// pngChunk returns a PNG chunk with its length and checksum
+func pngChunk(typ string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, typ...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// withPNGSize claims other dimensions in the header of a PNG, leaving its
+// pixel data as it is
+func withPNGSize(data []byte, width, height int) []byte {
+	out := append([]byte(nil), data...)
+	binary.BigEndian.PutUint32(out[16:20], uint32(width))
+	binary.BigEndian.PutUint32(out[20:24], uint32(height))
+	binary.BigEndian.PutUint32(out[29:33], crc32.ChecksumIEEE(out[12:29]))
+	return out
+}
+
+// exif returns an EXIF payload with the orientation tag, followed by extra
+// bytes standing in for the rest of the metadata
+func exif(order binary.AppendByteOrder, orientation int, extra string) []byte {
+	tiff := []byte("II")
+	if order == binary.BigEndian {
+		tiff = []byte("MM")
+	}
+	tiff = order.AppendUint16(tiff, 42)
+	tiff = order.AppendUint32(tiff, 8)
+	tiff = order.AppendUint16(tiff, 1)
+	tiff = order.AppendUint16(tiff, 0x0112)
+	tiff = order.AppendUint16(tiff, 3)
+	tiff = order.AppendUint32(tiff, 1)
+	tiff = order.AppendUint16(tiff, uint16(orientation))
+	tiff = order.AppendUint16(tiff, 0)
+	tiff = order.AppendUint32(tiff, 0)
+	return append(append([]byte("Exif\x00\x00"), tiff...), extra...)
+}
+
+// withEXIF inserts an APP1 segment holding payload after the SOI of a JPEG
+func withEXIF(data, payload []byte) []byte {
+	out := append([]byte(nil), data[:2]...)
+	out = append(out, 0xFF, 0xE1)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(payload)+2))
+	out = append(out, payload...)
+	return append(out, data[2:]...)
+}
+
+// webpChunk returns a RIFF chunk, padded to an even length
+func webpChunk(fourCC string, data []byte) []byte {
+	chunk := binary.LittleEndian.AppendUint32([]byte(fourCC), uint32(len(data)))
+	chunk = append(chunk, data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+func riff(chunks ...[]byte) []byte {
+	body := []byte("WEBP")
+	for _, chunk := range chunks {
+		body = append(body, chunk...)
+	}
+	out := binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body)))
+	return append(out, body...)
+}
+
+// chunks lists the four-character codes of the chunks of a RIFF container
+func chunks(data []byte) []string {
+	var fourCCs []string
+	for pos := 12; pos+8 <= len(data); {
+		fourCCs = append(fourCCs, string(data[pos:pos+4]))
+		length := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		pos += 8 + length + length%2
+	}
+	return fourCCs
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want Format
+		err  error
+	}{
+		{"jpeg", []byte("\xFF\xD8\xFF\xE0rest"), JPEG, nil},
+		{"png", []byte("\x89PNG\r\n\x1a\nrest"), PNG, nil},
+		{"gif87a", []byte("GIF87a rest"), GIF, nil},
+		{"gif89a", []byte("GIF89a rest"), GIF, nil},
+		{"webp", tinyWebP, WebP, nil},
+		{"riff but not webp", []byte("RIFF\x04\x00\x00\x00WAVEfmt "), Format{}, ErrUnsupportedFormat},
+		{"html named .jpg", []byte("<html><script>alert(1)</script></html>"), Format{}, ErrUnsupportedFormat},
+		{"php named .png", []byte("<?php system($_GET['c']); ?>"), Format{}, ErrUnsupportedFormat},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), Format{}, ErrUnsupportedFormat},
+		{"truncated gif", []byte("GIF8"), Format{}, ErrUnsupportedFormat},
+		{"empty", nil, Format{}, ErrUnsupportedFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Detect(tt.data)
+			if got != tt.want || !errors.Is(err, tt.err) {
+				t.Errorf("Detect = %v, %v, want %v, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	small := encodePNG(t, newImage(1, 1))
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		// A script behind valid magic bytes fails to decode
+		{"script behind jpeg magic", []byte("\xFF\xD8\xFF<?php system($_GET['c']); ?>"), ErrCorrupt},
+		{"script behind png magic", []byte("\x89PNG\r\n\x1a\n<?php system($_GET['c']); ?>"), ErrCorrupt},
+		{"text", []byte("just some text"), ErrUnsupportedFormat},
+		// The headers claim more pixels than the data holds, so decoding
+		// would fail: getting ErrTooManyPixels shows they are rejected first
+		{"too wide", withPNGSize(small, MaxDimension+1, 1), ErrTooManyPixels},
+		{"too tall", withPNGSize(small, 1, MaxDimension+1), ErrTooManyPixels},
+		{"too many pixels", withPNGSize(small, 6000, 6000), ErrTooManyPixels},
+		{"decompression bomb", withPNGSize(small, 100_000, 100_000), ErrTooManyPixels},
+		{"no pixels", withPNGSize(small, 0, 1), ErrCorrupt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Process(tt.data, nil); !errors.Is(err, tt.want) {
+				t.Errorf("Process error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDimensions(t *testing.T) {
+	tests := []struct {
+		width, height int
+		want          error
+	}{
+		{1, 1, nil},
+		{MaxDimension, MaxPixels / MaxDimension, nil},
+		{MaxDimension + 1, 1, ErrTooManyPixels},
+		{1, MaxDimension + 1, ErrTooManyPixels},
+		{MaxPixels / 5000, 5001, ErrTooManyPixels},
+		{0, 1, ErrCorrupt},
+		{1, -1, ErrCorrupt},
+	}
+	for _, tt := range tests {
+		if err := checkDimensions(tt.width, tt.height); !errors.Is(err, tt.want) {
+			t.Errorf("checkDimensions(%d, %d) = %v, want %v", tt.width, tt.height, err, tt.want)
+		}
+	}
+}
+
+func TestProcessGIFFrames(t *testing.T) {
+	// Small frames on a large canvas: every frame is decoded at the size of
+	// the canvas, so it is the canvas that counts
+	animation := func(frames int) []byte {
+		t.Helper()
+		anim := &gif.GIF{Config: image.Config{ColorModel: color.Palette(palette.Plan9), Width: 3000, Height: 3000}}
+		for i := 0; i < frames; i++ {
+			anim.Image = append(anim.Image, image.NewPaletted(image.Rect(0, 0, 1, 1), palette.Plan9))
+			anim.Delay = append(anim.Delay, 10)
+		}
+		var buf bytes.Buffer
+		if err := gif.EncodeAll(&buf, anim); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		frames int
+		want   error
+	}{
+		{1, nil},
+		{2, nil},
+		{MaxPixels/(3000*3000) + 1, ErrTooManyPixels},
+		{100, ErrTooManyPixels},
+	}
+	for _, tt := range tests {
+		data := animation(tt.frames)
+		if n, err := gifFrames(data); err != nil || n != tt.frames {
+			t.Errorf("gifFrames = %d, %v, want %d", n, err, tt.frames)
+		}
+		if _, err := Process(data, nil); !errors.Is(err, tt.want) {
+			t.Errorf("%d frames: Process error = %v, want %v", tt.frames, err, tt.want)
+		}
+	}
+
+	if _, err := gifFrames(animation(2)[:40]); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("gifFrames of a truncated GIF: error = %v, want ErrCorrupt", err)
+	}
+}
+
+func TestProcessStripsMetadata(t *testing.T) {
+	const secret = "GPS 52.3676N 4.9041E"
+
+	jpegData := withEXIF(encodeJPEG(t, newImage(8, 8)), exif(binary.LittleEndian, 1, secret))
+
+	pngData := encodePNG(t, newImage(8, 8))
+	iend := len(pngData) - 12
+	pngData = append(append(append([]byte(nil), pngData[:iend]...), pngChunk("tEXt", []byte("Comment\x00"+secret))...), pngData[iend:]...)
+
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, newImage(8, 8), nil); err != nil {
+		t.Fatal(err)
+	}
+	gifData := buf.Bytes()
+	comment := append([]byte{0x21, 0xFE, byte(len(secret))}, secret...)
+	gifData = append(append(append([]byte(nil), gifData[:len(gifData)-1]...), append(comment, 0)...), 0x3B)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"jpeg exif", jpegData},
+		{"png text", pngData},
+		{"gif comment", gifData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !bytes.Contains(tt.data, []byte(secret)) {
+				t.Fatal("the test image lacks its metadata")
+			}
+			img, err := Process(tt.data, []int{4})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Contains(img.Data, []byte(secret)) || bytes.Contains(img.Data, []byte("Exif\x00\x00")) {
+				t.Error("the metadata was kept")
+			}
+			if img.Width != 8 || img.Height != 8 {
+				t.Errorf("size = %dx%d, want 8x8", img.Width, img.Height)
+			}
+		})
+	}
+}
+
+func TestStripWebP(t *testing.T) {
+	simple := tinyWebP[12:]
+	// VP8X with the EXIF and XMP flags set and a 1x1 canvas
+	vp8x := []byte{webpFlagEXIF | webpFlagXMP, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	extended := riff(
+		webpChunk("VP8X", vp8x),
+		simple,
+		webpChunk("EXIF", exif(binary.BigEndian, 6, "odd")),
+		webpChunk("XMP ", []byte("<x:xmpmeta>secret</x:xmpmeta>")),
+	)
+
+	tests := []struct {
+		name string
+		data []byte
+		want []string
+	}{
+		{"simple", tinyWebP, []string{"VP8L"}},
+		{"extended", extended, []string{"VP8X", "VP8L"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stripped, err := stripWebP(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := chunks(stripped); len(got) != len(tt.want) || got[0] != tt.want[0] || got[len(got)-1] != tt.want[len(tt.want)-1] {
+				t.Errorf("chunks = %q, want %q", got, tt.want)
+			}
+			if size := int(binary.LittleEndian.Uint32(stripped[4:8])); size != len(stripped)-8 {
+				t.Errorf("RIFF size = %d, want %d", size, len(stripped)-8)
+			}
+			if tt.want[0] == "VP8X" && stripped[20]&(webpFlagEXIF|webpFlagXMP) != 0 {
+				t.Errorf("VP8X flags = %#x, the metadata flags are still set", stripped[20])
+			}
+			if _, err := webp.Decode(bytes.NewReader(stripped)); err != nil {
+				t.Errorf("the stripped image does not decode: %v", err)
+			}
+		})
+	}
+
+	img, err := Process(extended, []int{100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(img.Data, []byte("secret")) || img.VariantFormat != PNG {
+		t.Errorf("Process kept the metadata or encoded variants as %s", img.VariantFormat.Name)
+	}
+
+	// Chunks running past the end of the container are rejected
+	truncated := append([]byte(nil), tinyWebP...)
+	binary.LittleEndian.PutUint32(truncated[16:20], 1000)
+	for _, data := range [][]byte{truncated, tinyWebP[:len(tinyWebP)-4]} {
+		if _, err := stripWebP(data); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("stripWebP error = %v, want ErrCorrupt", err)
+		}
+	}
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	plain := encodeJPEG(t, newImage(4, 4))
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"no exif", plain, 1},
+		{"little endian", withEXIF(plain, exif(binary.LittleEndian, 6, "")), 6},
+		{"big endian", withEXIF(plain, exif(binary.BigEndian, 8, "")), 8},
+		{"out of range", withEXIF(plain, exif(binary.LittleEndian, 9, "")), 1},
+		{"truncated", withEXIF(plain, exif(binary.LittleEndian, 3, "")[:14]), 1},
+	}
+	for _, tt := range tests {
+		if got := jpegOrientation(tt.data); got != tt.want {
+			t.Errorf("%s: jpegOrientation = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// A 3x2 image with a red top-left and a green top-middle pixel
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	src.Set(0, 0, red)
+	src.Set(1, 0, green)
+
+	tests := []struct {
+		orientation   int
+		width, height int
+		red, green    image.Point
+	}{
+		{1, 3, 2, image.Pt(0, 0), image.Pt(1, 0)},
+		{2, 3, 2, image.Pt(2, 0), image.Pt(1, 0)},
+		{3, 3, 2, image.Pt(2, 1), image.Pt(1, 1)},
+		{4, 3, 2, image.Pt(0, 1), image.Pt(1, 1)},
+		{5, 2, 3, image.Pt(0, 0), image.Pt(0, 1)},
+		{6, 2, 3, image.Pt(1, 0), image.Pt(1, 1)},
+		{7, 2, 3, image.Pt(1, 2), image.Pt(1, 1)},
+		{8, 2, 3, image.Pt(0, 2), image.Pt(0, 1)},
+	}
+	for _, tt := range tests {
+		dst := orient(src, tt.orientation)
+		if b := dst.Bounds(); b.Dx() != tt.width || b.Dy() != tt.height {
+			t.Errorf("orientation %d: size = %dx%d, want %dx%d", tt.orientation, b.Dx(), b.Dy(), tt.width, tt.height)
+			continue
+		}
+		if got := color.RGBAModel.Convert(dst.At(tt.red.X, tt.red.Y)); got != red {
+			t.Errorf("orientation %d: %v = %v, want red", tt.orientation, tt.red, got)
+		}
+		if got := color.RGBAModel.Convert(dst.At(tt.green.X, tt.green.Y)); got != green {
+			t.Errorf("orientation %d: %v = %v, want green", tt.orientation, tt.green, got)
+		}
+	}
+
+	// Process applies the orientation before dropping the EXIF holding it
+	img, err := Process(withEXIF(encodeJPEG(t, newImage(40, 20)), exif(binary.LittleEndian, 6, "")), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Width != 20 || img.Height != 40 {
+		t.Errorf("size = %dx%d, want 20x40", img.Width, img.Height)
+	}
+}
+
+func TestProcessVariants(t *testing.T) {
+	img, err := Process(encodePNG(t, newImage(100, 50)), []int{40, 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		width        int
+		wantW, wantH int
+	}{
+		{40, 40, 20},
+		// Never upscaled
+		{200, 100, 50},
+	}
+	for _, tt := range tests {
+		config, err := png.DecodeConfig(bytes.NewReader(img.Variants[tt.width]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Width != tt.wantW || config.Height != tt.wantH {
+			t.Errorf("variant %d is %dx%d, want %dx%d", tt.width, config.Width, config.Height, tt.wantW, tt.wantH)
+		}
+	}
+}
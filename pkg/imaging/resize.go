@@ -0,0 +1,65 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+// resize scales img down to width, keeping its aspect ratio. Each target
+// pixel is the average of the source pixels it covers, which avoids the
+// aliasing of nearest-neighbour sampling. Images already narrower than width
+// are returned as they are.
+func resize(img image.Image, width int) image.Image {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if width <= 0 || width >= sw {
+		return img
+	}
+	height := sh * width / sw
+	if height < 1 {
+		height = 1
+	}
+
+	// Work on premultiplied RGBA so transparent pixels don't bleed colour
+	src, ok := img.(*image.RGBA)
+	if !ok || b.Min != (image.Point{}) {
+		src = image.NewRGBA(image.Rect(0, 0, sw, sh))
+		draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := y * sh / height
+		y1 := (y + 1) * sh / height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := x * sw / width
+			x1 := (x + 1) * sw / width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, bl, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint32(p[0])
+					g += uint32(p[1])
+					bl += uint32(p[2])
+					a += uint32(p[3])
+					n++
+				}
+			}
+
+			p := dst.Pix[y*dst.Stride+x*4:]
+			p[0] = uint8(r / n)
+			p[1] = uint8(g / n)
+			p[2] = uint8(bl / n)
+			p[3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,67 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// VP8X feature flags for the metadata chunks that get stripped
+const (
+	webpFlagXMP  = 0x04
+	webpFlagEXIF = 0x08
+)
+
+// stripWebP rewrites the RIFF container of a WebP image without its EXIF
+// and XMP chunks. The image data is kept as is.
+func stripWebP(data []byte) ([]byte, error) {
+	size := int(binary.LittleEndian.Uint32(data[4:8]))
+	if size < 4 || size+8 > len(data) {
+		return nil, ErrCorrupt
+	}
+	data = data[:size+8]
+
+	var out bytes.Buffer
+	out.WriteString("RIFF\x00\x00\x00\x00WEBP")
+
+	for pos := 12; pos < len(data); {
+		if pos+8 > len(data) {
+			return nil, ErrCorrupt
+		}
+		fourCC := string(data[pos : pos+4])
+		length := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		end := pos + 8 + length
+		if length < 0 || end > len(data) {
+			return nil, ErrCorrupt
+		}
+		chunk := data[pos+8 : end]
+		// Chunks are padded to an even length
+		next := end + length%2
+
+		switch fourCC {
+		case "EXIF", "XMP ":
+			pos = next
+			continue
+		case "VP8X":
+			if length < 10 {
+				return nil, ErrCorrupt
+			}
+			stripped := append([]byte(nil), chunk...)
+			stripped[0] &^= webpFlagEXIF | webpFlagXMP
+			chunk = stripped
+		}
+
+		var header [8]byte
+		copy(header[:4], fourCC)
+		binary.LittleEndian.PutUint32(header[4:], uint32(len(chunk)))
+		out.Write(header[:])
+		out.Write(chunk)
+		if len(chunk)%2 == 1 {
+			out.WriteByte(0)
+		}
+		pos = next
+	}
+
+	stripped := out.Bytes()
+	binary.LittleEndian.PutUint32(stripped[4:8], uint32(len(stripped)-8))
+	return stripped, nil
+}
@@ -1,5 +0,0 @@
-package jwt
-
-type contextKey string
-
-const UserIDKey contextKey = "user_id"
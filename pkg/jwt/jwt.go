@@ -1,22 +1,57 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v5"
 )
 
-func GenerateJWTToken(userID int, role string, secretKey string) (string, error) {
-	// Define claims for the jwt
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"role":    role,
-		"exp":     time.Now().Add(time.Hour * 72).Unix(), // Token expiration
+// Signer issues access tokens with the current key of a key set
+type Signer struct {
+	keys     *KeySet
+	issuer   string
+	audience string
+}
+
+func NewSigner(keys *KeySet, issuer, audience string) *Signer {
+	return &Signer{keys: keys, issuer: issuer, audience: audience}
+}
+
+// GenerateAccessToken issues a short-lived access token granted the scopes.
+// Each token gets a unique ID (jti), so it can be revoked before it expires,
+// and names the key it was signed with (kid).
+func (s *Signer) GenerateAccessToken(userID int, role string, scopes []string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	key, err := s.keys.signingKey(now)
+	if err != nil {
+		return "", err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	claims := &Claims{
+		UserID: userID,
+		Role:   role,
+		Scope:  strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        hex.EncodeToString(id),
+			Issuer:    s.issuer,
+			Audience:  jwt.ClaimStrings{s.audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
 	}
 
 	// Create a new JWT token with the claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.ID
 
 	// Sign and return the token
-	return token.SignedString([]byte(secretKey))
+	return token.SignedString(key.private)
 }
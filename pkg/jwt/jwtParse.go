@@ -1,67 +1,104 @@
 package jwt
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrMissingToken is returned when a request carries no bearer token
+	ErrMissingToken = errors.New("no token found")
+
+	// ErrInvalidToken is returned for malformed, forged and expired tokens
+	ErrInvalidToken = errors.New("invalid or expired token")
+
+	// ErrTokenRevoked is returned for tokens revoked by logging out or by a
+	// change of the user's role
+	ErrTokenRevoked = fmt.Errorf("%w: token has been revoked", ErrInvalidToken)
 )
 
 type Claims struct {
 	UserID int    `json:"user_id"`
 	Role   string `json:"role"`
-	jwt.StandardClaims
+	// Scope lists the scopes granted to the token, separated by spaces
+	Scope string `json:"scope,omitempty"`
+	jwt.RegisteredClaims
 }
 
-// ParseJWTToken parses the JWT token and returns the claims
-func ParseJWTToken(tokenString, secretKey string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secretKey), nil
-	})
-	if err != nil || !token.Valid {
-		return nil, err
-	}
-	return token.Claims.(jwt.MapClaims), nil
+// Denylist tells whether an access token was revoked before it expired
+type Denylist interface {
+	IsTokenRevoked(ctx context.Context, claims *Claims) (bool, error)
 }
 
-// ExtractRoleFromToken extracts the role from the JWT token.
-func ExtractRoleFromToken(r *http.Request, secretKey string) (string, error) {
-	// Get the token from the Authorization header
-	tokenString := r.Header.Get("Authorization")
-	tokenString = strings.Replace(tokenString, "Bearer ", "", 1)
+// Verifier checks access tokens: their signature, issuer, audience, expiry
+// and that they have not been revoked
+type Verifier struct {
+	keys     *KeySet
+	denylist Denylist
+	parser   *jwt.Parser
+}
 
-	// Parse the token
-	claims, err := ParseJWTToken(tokenString, secretKey)
-	if err != nil {
-		return "", err
+func NewVerifier(keys *KeySet, issuer, audience string, denylist Denylist) *Verifier {
+	return &Verifier{
+		keys:     keys,
+		denylist: denylist,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{AlgRS256, AlgEdDSA}),
+			jwt.WithIssuer(issuer),
+			jwt.WithAudience(audience),
+			jwt.WithExpirationRequired(),
+		),
+	}
+}
+
+// Verify parses an access token and returns its claims
+func (v *Verifier) Verify(ctx context.Context, tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := v.parser.ParseWithClaims(tokenString, claims, v.publicKey)
+	// Tokens without an ID predate revocation and are no longer accepted
+	if err != nil || !token.Valid || claims.ID == "" {
+		return nil, ErrInvalidToken
 	}
 
-	// Extract the role
-	role, ok := claims["role"].(string)
-	if !ok {
-		return "", errors.New("role not found in token")
+	if v.denylist != nil {
+		revoked, err := v.denylist.IsTokenRevoked(ctx, claims)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
+		}
 	}
+	return claims, nil
+}
 
-	return role, nil
+// publicKey looks up the key a token names. The algorithm is pinned to the
+// key, so a token cannot pick how its signature is checked.
+func (v *Verifier) publicKey(token *jwt.Token) (interface{}, error) {
+	id, _ := token.Header["kid"].(string)
+	key := v.keys.verificationKey(id, time.Now())
+	if key == nil {
+		return nil, fmt.Errorf("unknown signing key %q", id)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method %v for key %s", token.Header["alg"], key.ID)
+	}
+	return key.private.Public(), nil
 }
 
-func ExtractClaims(r *http.Request, secretKey string) (*Claims, error) {
+// FromRequest verifies the bearer token in the Authorization header
+func (v *Verifier) FromRequest(r *http.Request) (*Claims, error) {
 	tokenString := getTokenFromHeader(r)
 	if tokenString == "" {
-		return nil, errors.New("no token found")
-	}
-
-	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secretKey), nil
-	})
-
-	if err != nil || !token.Valid {
-		return nil, err
+		return nil, ErrMissingToken
 	}
-
-	return claims, nil
+	return v.Verify(r.Context(), tokenString)
 }
 
 func getTokenFromHeader(r *http.Request) string {
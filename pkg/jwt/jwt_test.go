@@ -0,0 +1,160 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type denylist map[string]bool
+
+func (d denylist) IsTokenRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	return d[claims.ID], nil
+}
+
+func newKeySet(t *testing.T, algorithm string) (*KeySet, *Key) {
+	t.Helper()
+	key, err := GenerateKey(algorithm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.NotBefore = time.Now().Add(-time.Minute)
+	keys := NewKeySet()
+	keys.Replace([]*Key{key})
+	return keys, key
+}
+
+func TestSignAndVerify(t *testing.T) {
+	for _, algorithm := range []string{AlgRS256, AlgEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			keys, key := newKeySet(t, algorithm)
+			signer := NewSigner(keys, "blog-api", "blog-api")
+			verifier := NewVerifier(keys, "blog-api", "blog-api", nil)
+
+			token, err := signer.GenerateAccessToken(7, "author", []string{"blog:write", "comment:write"}, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			claims, err := verifier.Verify(context.Background(), token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.UserID != 7 || claims.Role != "author" || claims.Scope != "blog:write comment:write" || claims.ID == "" {
+				t.Errorf("unexpected claims %+v", claims)
+			}
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.Header["kid"] != key.ID || parsed.Header["alg"] != algorithm {
+				t.Errorf("header = %v, want kid %s and alg %s", parsed.Header, key.ID, algorithm)
+			}
+		})
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	keys, key := newKeySet(t, AlgRS256)
+	otherKeys, _ := newKeySet(t, AlgRS256)
+	revoked := denylist{}
+	verifier := NewVerifier(keys, "blog-api", "blog-api", revoked)
+
+	sign := func(t *testing.T, keys *KeySet, issuer, audience string, ttl time.Duration) string {
+		t.Helper()
+		token, err := NewSigner(keys, issuer, audience).GenerateAccessToken(1, "user", nil, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	// Signed with the private key bytes as an HMAC secret, a classic
+	// algorithm confusion attack
+	der, err := key.PrivateKeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: 1, RegisteredClaims: jwt.RegisteredClaims{
+		ID: "x", Issuer: "blog-api", Audience: jwt.ClaimStrings{"blog-api"}, ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}})
+	hmacToken.Header["kid"] = key.ID
+	forged, err := hmacToken.SignedString(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := sign(t, keys, "blog-api", "blog-api", time.Minute)
+	parts := strings.Split(valid, ".")
+	unsigned := parts[0] + "." + parts[1] + "."
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"expired", sign(t, keys, "blog-api", "blog-api", -time.Minute), ErrInvalidToken},
+		{"wrong issuer", sign(t, keys, "other", "blog-api", time.Minute), ErrInvalidToken},
+		{"wrong audience", sign(t, keys, "blog-api", "other", time.Minute), ErrInvalidToken},
+		{"unknown key", sign(t, otherKeys, "blog-api", "blog-api", time.Minute), ErrInvalidToken},
+		{"HMAC forgery", forged, ErrInvalidToken},
+		{"missing signature", unsigned, ErrInvalidToken},
+		{"garbage", "not.a.token", ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := verifier.Verify(context.Background(), tt.token); !errors.Is(err, tt.want) {
+				t.Errorf("Verify error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("revoked", func(t *testing.T) {
+		claims, err := verifier.Verify(context.Background(), valid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		revoked[claims.ID] = true
+		if _, err := verifier.Verify(context.Background(), valid); !errors.Is(err, ErrTokenRevoked) {
+			t.Errorf("Verify error = %v, want ErrTokenRevoked", err)
+		}
+	})
+}
+
+func TestKeyRoundTrip(t *testing.T) {
+	for _, algorithm := range []string{AlgRS256, AlgEdDSA} {
+		key, err := GenerateKey(algorithm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pem, err := key.PrivateKeyPEM()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParseKey(key.ID, algorithm, pem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.JWK() != key.JWK() {
+			t.Errorf("%s: JWK changed after a round trip", algorithm)
+		}
+	}
+
+	// A key cannot be loaded for an algorithm of another key type
+	key, err := GenerateKey(AlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pem, err := key.PrivateKeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseKey(key.ID, AlgRS256, pem); err == nil {
+		t.Error("ParseKey accepted an Ed25519 key for RS256")
+	}
+}
@@ -0,0 +1,222 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algorithms access tokens can be signed with
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// rsaKeyBits is the size of generated RSA keys
+const rsaKeyBits = 2048
+
+// ErrNoSigningKey is returned when a key set has no key to sign with yet
+var ErrNoSigningKey = errors.New("no signing key is active")
+
+// Key is a private signing key identified by the kid of the tokens it signs.
+// A key signs from NotBefore until a newer key takes over, and tokens it
+// signed are accepted until NotAfter, which is zero while no newer key is
+// scheduled.
+type Key struct {
+	ID        string
+	Algorithm string
+	NotBefore time.Time
+	NotAfter  time.Time
+
+	private crypto.Signer
+}
+
+// GenerateKey creates a key with a random ID for the given algorithm
+func GenerateKey(algorithm string) (*Key, error) {
+	var private crypto.Signer
+	switch algorithm {
+	case AlgRS256:
+		key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, err
+		}
+		private = key
+	case AlgEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		private = key
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q, use %s or %s", algorithm, AlgRS256, AlgEdDSA)
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return &Key{ID: hex.EncodeToString(id), Algorithm: algorithm, private: private}, nil
+}
+
+// ParseKey reads a PEM encoded PKCS #8 private key, which has to be of the
+// type the algorithm signs with
+func ParseKey(id, algorithm string, privateKeyPEM []byte) (*Key, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("key %s: no PEM data found", id)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", id, err)
+	}
+
+	var private crypto.Signer
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		if algorithm == AlgRS256 {
+			private = key
+		}
+	case ed25519.PrivateKey:
+		if algorithm == AlgEdDSA {
+			private = key
+		}
+	}
+	if private == nil {
+		return nil, fmt.Errorf("key %s: %T cannot sign %s", id, parsed, algorithm)
+	}
+	return &Key{ID: id, Algorithm: algorithm, private: private}, nil
+}
+
+// PrivateKeyPEM encodes the private key as PEM encoded PKCS #8
+func (k *Key) PrivateKeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.private)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func (k *Key) method() jwt.SigningMethod {
+	if k.Algorithm == AlgEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// expired reports whether tokens signed by the key are no longer accepted
+func (k *Key) expired(now time.Time) bool {
+	return !k.NotAfter.IsZero() && !now.Before(k.NotAfter)
+}
+
+// JWK is the public part of a key as a JSON Web Key (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	// N and E are the modulus and exponent of RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Curve and X describe Ed25519 keys
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKS is the JSON Web Key Set other services verify access tokens with
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK returns the public key
+func (k *Key) JWK() JWK {
+	jwk := JWK{Use: "sig", Algorithm: k.Algorithm, KeyID: k.ID}
+	switch public := k.private.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+	return jwk
+}
+
+// KeySet holds the keys tokens are signed and verified with. It is safe for
+// concurrent use, and replacing the keys takes effect for the next token.
+type KeySet struct {
+	mu   sync.RWMutex
+	keys []*Key
+}
+
+func NewKeySet() *KeySet {
+	return &KeySet{}
+}
+
+// Replace swaps in a new set of keys
+func (s *KeySet) Replace(keys []*Key) {
+	sorted := make([]*Key, len(keys))
+	copy(sorted, keys)
+	// Newest first, so the first active key is the one to sign with
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].NotBefore.After(sorted[j].NotBefore) })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = sorted
+}
+
+// signingKey returns the newest key that has started signing
+func (s *KeySet) signingKey(now time.Time) (*Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.keys {
+		if !key.NotBefore.After(now) && !key.expired(now) {
+			return key, nil
+		}
+	}
+	return nil, ErrNoSigningKey
+}
+
+// verificationKey returns the key with the given ID, unless its tokens are
+// no longer accepted
+func (s *KeySet) verificationKey(id string, now time.Time) *Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.keys {
+		if key.ID == id && !key.expired(now) {
+			return key
+		}
+	}
+	return nil
+}
+
+// JWKS returns the public keys tokens may be signed with. Keys are published
+// before they start signing, so verifiers can fetch them ahead of time.
+func (s *KeySet) JWKS(now time.Time) *JWKS {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := &JWKS{Keys: []JWK{}}
+	for _, key := range s.keys {
+		if !key.expired(now) {
+			set.Keys = append(set.Keys, key.JWK())
+		}
+	}
+	return set
+}
@@ -0,0 +1,43 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+)
+
+// Header carries the request ID in both directions
+const Header = "X-Request-ID"
+
+type contextKey struct{}
+
+// valid matches request IDs accepted from clients and proxies
+var valid = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Middleware tags every request with an ID, reusing the one sent by the
+// client or a proxy when it looks sane, and echoes it in the response
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !valid.MatchString(id) {
+			id = generate()
+		}
+
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, id)))
+	})
+}
+
+// FromContext returns the ID of the request, or "" outside of Middleware
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+func generate() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,73 @@
+package response
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"blog-api/pkg/requestid"
+)
+
+// Machine-readable error codes used in the error envelope
+const (
+	CodeBadRequest       = "bad_request"
+	CodeValidation       = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodeTimeout          = "timeout"
+	CodeInternal         = "internal_error"
+)
+
+// FieldError describes why a single request field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type envelope struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Details   []FieldError `json:"details,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// JSON writes v as a JSON response with the given status
+func JSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// Created writes a 201 response with the new resource and its Location
+func Created(w http.ResponseWriter, location string, v interface{}) {
+	w.Header().Set("Location", location)
+	JSON(w, http.StatusCreated, v)
+}
+
+// Error writes the error envelope:
+//
+//	{"error": {"code": "...", "message": "...", "details": [...], "request_id": "..."}}
+func Error(w http.ResponseWriter, r *http.Request, status int, code, message string, details ...FieldError) {
+	JSON(w, status, envelope{Error: errorBody{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: requestid.FromContext(r.Context()),
+	}})
+}
+
+// Internal logs an unexpected error and writes a generic 500 response, so
+// internals never leak to clients. The request ID ties the two together.
+func Internal(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("Internal error [%s] %s %s: %v", requestid.FromContext(r.Context()), r.Method, r.URL.Path, err)
+	Error(w, r, http.StatusInternalServerError, CodeInternal, "Something went wrong")
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Local stores objects as files below a root directory
+type Local struct {
+	root    string
+	baseURL string
+}
+
+// NewLocal returns a storage rooted at dir. baseURL is the URL the API
+// serves media below, such as "https://example.com/api/v1", and is used to
+// build object URLs.
+func NewLocal(dir, baseURL string) *Local {
+	return &Local{root: dir, baseURL: baseURL}
+}
+
+func (s *Local) path(key string) (string, error) {
+	clean, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
+
+func (s *Local) Put(ctx context.Context, key string, body io.ReadSeeker, contentType string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), os.ModePerm); err != nil {
+		return err
+	}
+
+	// Write to a temporary file next to the target and move it into place,
+	// so readers never see a partially written object
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (s *Local) Exists(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	name, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = os.Stat(name)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *Local) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// SignedURL fails: local files are served to anyone through the media route
+// and nothing could make a URL to them expire. Media that must stay private
+// needs the S3 driver.
+func (s *Local) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return "", ErrSignedURLUnsupported
+}
+
+func (s *Local) Open(key string) (*Object, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, ErrNotFound
+	}
+	return &Object{ReadSeekCloser: file, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
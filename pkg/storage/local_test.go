@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	s := NewLocal(t.TempDir(), "http://localhost/api/v1")
+
+	if err := s.Put(ctx, "uploads/a.txt", bytes.NewReader([]byte("hello")), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	object, err := s.Open("uploads/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(object)
+	object.Close()
+	if err != nil || string(data) != "hello" {
+		t.Errorf("Open = %q, %v, want hello", data, err)
+	}
+
+	for _, key := range []string{"../escape", "/etc/passwd", `uploads\a.txt`, ""} {
+		if err := s.Put(ctx, key, bytes.NewReader(nil), "text/plain"); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q) error = %v, want ErrInvalidKey", key, err)
+		}
+	}
+
+	// Local files are public, so there is no URL that expires
+	if _, err := s.SignedURL(ctx, "uploads/a.txt", time.Minute); !errors.Is(err, ErrSignedURLUnsupported) {
+		t.Errorf("SignedURL error = %v, want ErrSignedURLUnsupported", err)
+	}
+
+	if err := s.Delete(ctx, "uploads/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Open("uploads/a.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open after Delete: error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"blog-api/config"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// ErrInvalidKey is returned for keys that are empty or escape the storage root
+var ErrInvalidKey = errors.New("invalid object key")
+
+// ErrSignedURLUnsupported is returned by backends that cannot grant
+// expiring access to an object
+var ErrSignedURLUnsupported = errors.New("signed URLs are not supported by this storage")
+
+// Storage stores uploaded media under slash-separated keys such as
+// "uploads/<hash>.jpg". Calls give up once their context is done.
+type Storage interface {
+	// Put stores body under key, replacing any existing object
+	Put(ctx context.Context, key string, body io.ReadSeeker, contentType string) error
+	// Exists reports whether an object is stored under key
+	Exists(ctx context.Context, key string) (bool, error)
+	// Delete removes the object under key. Missing objects are not an error.
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL granting read access to key until it expires,
+	// or ErrSignedURLUnsupported when the backend cannot make one
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// Object is an opened object along with the metadata needed to serve it
+type Object struct {
+	io.ReadSeekCloser
+	Size    int64
+	ModTime time.Time
+}
+
+// Opener is implemented by backends that can stream objects themselves.
+// Objects in other backends are served by redirecting to a signed URL.
+type Opener interface {
+	Open(key string) (*Object, error)
+}
+
+// cleanKey normalizes key and rejects keys that are absolute or contain ".."
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return "", ErrInvalidKey
+	}
+	clean := path.Clean(key)
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", ErrInvalidKey
+	}
+	return clean, nil
+}
+
+// New returns the storage backend selected by cfg.StorageDriver. mediaURL
+// is where the API serves locally stored files.
+func New(cfg *config.Config, mediaURL string) (Storage, error) {
+	switch cfg.StorageDriver {
+	case "", "local":
+		return NewLocal(cfg.StorageDir, mediaURL), nil
+	case "s3":
+		s3, err := NewS3(S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return s3, nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.StorageDriver)
+	}
+}
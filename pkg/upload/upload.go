@@ -0,0 +1,148 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	"blog-api/pkg/imaging"
+	"blog-api/pkg/storage"
+)
+
+// Dir is the directory uploaded files are stored in
+const Dir = "uploads"
+
+// MaxImageSize is the largest image upload accepted, in bytes
+const MaxImageSize = 10 << 20
+
+// ErrTooLarge is returned for uploads over MaxImageSize
+var ErrTooLarge = fmt.Errorf("image must be at most %dMB", MaxImageSize>>20)
+
+// VariantWidths are the widths every uploaded image is rendered at
+var VariantWidths = []int{320, 800, 1600}
+
+// variantSource matches the keys of images stored with variants
+var variantSource = regexp.MustCompile(`^` + Dir + `/([0-9a-f]{64})\.(jpg|png|gif|webp)$`)
+
+// Image is a stored image
+type Image struct {
+	Key         string
+	ContentType string
+	Width       int
+	Height      int
+	Size        int
+}
+
+// Variant is a downscaled copy of an image
+type Variant struct {
+	Width int
+	Key   string
+}
+
+// SaveImage validates src as a JPEG, PNG, GIF or WebP image, strips its
+// metadata and stores it along with a variant for each of VariantWidths.
+// Keys are derived from the SHA-256 of the stripped image, so identical
+// uploads share one object and different uploads never collide. The key of
+// the stored image looks like "uploads/<hash>.jpg".
+//
+// hold is called with the key before anything is stored, so the caller can
+// keep a pending cleanup of an identical earlier upload from removing the
+// files while they are reused.
+func SaveImage(ctx context.Context, store storage.Storage, src io.Reader, hold func(ctx context.Context, key string) error) (*Image, error) {
+	data, err := io.ReadAll(io.LimitReader(src, MaxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxImageSize {
+		return nil, ErrTooLarge
+	}
+
+	img, err := imaging.Process(data, VariantWidths)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(img.Data)
+	hash := hex.EncodeToString(sum[:])
+	key := path.Join(Dir, hash+img.Format.Ext)
+	if err := hold(ctx, key); err != nil {
+		return nil, err
+	}
+
+	// Store the variants first, so the image never shows up without them.
+	// Images uploaded before may still lack some of them.
+	for _, variant := range Variants(key) {
+		if err := putMissing(ctx, store, variant.Key, img.Variants[variant.Width], img.VariantFormat.ContentType); err != nil {
+			return nil, err
+		}
+	}
+	if err := putMissing(ctx, store, key, img.Data, img.Format.ContentType); err != nil {
+		return nil, err
+	}
+	return &Image{
+		Key:         key,
+		ContentType: img.Format.ContentType,
+		Width:       img.Width,
+		Height:      img.Height,
+		Size:        len(img.Data),
+	}, nil
+}
+
+// putMissing stores data under key unless an object is already stored there
+func putMissing(ctx context.Context, store storage.Storage, key string, data []byte, contentType string) error {
+	exists, err := store.Exists(ctx, key)
+	if err != nil || exists {
+		return err
+	}
+	return store.Put(ctx, key, bytes.NewReader(data), contentType)
+}
+
+// Variants returns the variants stored alongside an image. Files stored
+// under legacy names have none.
+func Variants(key string) []Variant {
+	m := variantSource.FindStringSubmatch(key)
+	if m == nil {
+		return nil
+	}
+
+	// GIF and WebP variants are stored as PNG
+	ext := "." + m[2]
+	if ext == imaging.GIF.Ext || ext == imaging.WebP.Ext {
+		ext = imaging.PNG.Ext
+	}
+
+	variants := make([]Variant, 0, len(VariantWidths))
+	for _, width := range VariantWidths {
+		variants = append(variants, Variant{
+			Width: width,
+			Key:   fmt.Sprintf("%s/%s-%d%s", Dir, m[1], width, ext),
+		})
+	}
+	return variants
+}
+
+// Remove deletes a stored file along with its variants. Keys outside Dir
+// are refused and files that are already gone are not an error.
+func Remove(ctx context.Context, store storage.Storage, key string) error {
+	clean := path.Clean(key)
+	if !strings.HasPrefix(clean, Dir+"/") {
+		return errors.New("refusing to remove a file outside the uploads directory")
+	}
+
+	if err := store.Delete(ctx, clean); err != nil {
+		return err
+	}
+	for _, variant := range Variants(clean) {
+		if err := store.Delete(ctx, variant.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}